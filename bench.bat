@@ -1 +1,2 @@
-hyperfine -N "go run ./main.go --benchmark true" "go run ../sandgox-comparison/main.go --benchmark true"
\ No newline at end of file
+hyperfine -N "go run ./main.go --benchmark true" "go run ../sandgox-comparison/main.go --benchmark true"
+go test ./sim -run "^$" -bench Tick -benchmem
\ No newline at end of file
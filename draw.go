@@ -2,6 +2,8 @@ package main
 
 import (
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"go_project/sim"
 	"image/color"
 	"time"
 )
@@ -10,52 +12,88 @@ var (
 	screenBufferImg      *ebiten.Image
 	onlyShowUpdatedCells = false
 	updateAllCells       = false
-	onlyOneColor         = false
+	showAwakeChunks      = false
+	showTemperature      = false
+	// redrawAll makes the next frame draw every cell, for instance after the
+	// temperature overlay is switched off.
+	redrawAll = false
 )
 
-func groupRectanglesHorizontallyByColor(pointsByColor map[color.Color][][]bool) map[color.Color][]Rect {
-	rectanglesByColor := make(map[color.Color][]Rect)
-	for col, points := range pointsByColor {
-		for y, row := range points {
-			posX := 0
-			width := 0
-			for x, point := range row {
-				if point {
-					if width == 0 {
-						posX = x
-						width = 1
-					} else {
-						width++
-					}
-				} else if width > 0 {
-					rectanglesByColor[col] = append(rectanglesByColor[col], Rect{
-						x: posX,
-						y: y,
-						w: width,
-						h: 1,
-					})
-					width = 0
-				} else {
-					width = 0
-				}
-			}
-			if width > 0 {
-				rectanglesByColor[col] = append(rectanglesByColor[col], Rect{
-					x: posX,
-					y: y,
-					w: width,
-					h: 1,
-				})
-			}
+const (
+	temperatureBands = 64
+	coldestShown     = -50
+	hottestShown     = 1550
+)
+
+type temperatureStop struct {
+	temperature int
+	color       color.RGBA
+}
+
+// temperatureStops are the colours of the temperature overlay, interpolated
+// in between.
+var temperatureStops = []temperatureStop{
+	{coldestShown, color.RGBA{0, 0, 255, 255}},
+	{20, color.RGBA{0, 0, 0, 255}},
+	{300, color.RGBA{255, 0, 0, 255}},
+	{1000, color.RGBA{255, 255, 0, 255}},
+	{hottestShown, color.RGBA{255, 255, 255, 255}},
+}
+
+var temperatureColors = makeTemperatureColors()
+
+func makeTemperatureColors() []color.RGBA {
+	colors := make([]color.RGBA, temperatureBands)
+	for band := range colors {
+		temperature := coldestShown + band*(hottestShown-coldestShown)/(temperatureBands-1)
+		stop := 1
+		for stop < len(temperatureStops)-1 && temperatureStops[stop].temperature < temperature {
+			stop++
+		}
+		from := temperatureStops[stop-1]
+		to := temperatureStops[stop]
+		t := float64(temperature-from.temperature) / float64(to.temperature-from.temperature)
+		colors[band] = color.RGBA{
+			R: uint8(float64(from.color.R) + t*(float64(to.color.R)-float64(from.color.R))),
+			G: uint8(float64(from.color.G) + t*(float64(to.color.G)-float64(from.color.G))),
+			B: uint8(float64(from.color.B) + t*(float64(to.color.B)-float64(from.color.B))),
+			A: 255,
 		}
 	}
-	return rectanglesByColor
+	return colors
+}
+
+// paletteSize, cellColorIndex and paletteColor give the colours of the cells,
+// taken from their element or from their temperature when the overlay is on.
+func paletteSize() int {
+	if showTemperature {
+		return temperatureBands
+	}
+	return sim.PaletteSize()
 }
 
-var cachedRects = make([]*ebiten.Image, gridSize)
+func cellColorIndex(cell sim.Cell) int {
+	if showTemperature {
+		band := (int(cell.Temp) - coldestShown) * (temperatureBands - 1) / (hottestShown - coldestShown)
+		return max(min(band, temperatureBands-1), 0)
+	}
+	return cell.PaletteIndex()
+}
+
+func paletteColor(index int) color.RGBA {
+	if showTemperature {
+		return temperatureColors[index]
+	}
+	return sim.PaletteColor(index)
+}
+
+var cachedRects = make([]*ebiten.Image, 0)
 
 func getRectImageByWidth(width int) *ebiten.Image {
 	index := width - 1
+	if index >= len(cachedRects) {
+		cachedRects = append(cachedRects, make([]*ebiten.Image, index+1-len(cachedRects))...)
+	}
 	if cachedRects[index] != nil {
 		return cachedRects[index]
 	}
@@ -64,32 +102,56 @@ func getRectImageByWidth(width int) *ebiten.Image {
 	return rect
 }
 
-func groupUpdatedCellsByColor(g *Game) map[color.Color][][]bool {
-	pointsByColor := make(map[color.Color][][]bool)
-	for y := 0; y < gridSize; y++ {
-		for x := 0; x < gridSize; x++ {
-			if g.grid[y][x].isActive || updateAllCells {
-				if pointsByColor[g.grid[y][x].color] == nil {
-					pointsByColor[g.grid[y][x].color] = make([][]bool, gridSize)
-					for i := range pointsByColor[g.grid[y][x].color] {
-						pointsByColor[g.grid[y][x].color][i] = make([]bool, gridSize)
-						for j := range pointsByColor[g.grid[y][x].color][i] {
-							pointsByColor[g.grid[y][x].color][i][j] = false
-						}
-					}
+// groupUpdatedRectanglesByColor merges the updated cells of each row into
+// horizontal rectangles of the same colour. The result is indexed by palette
+// index and reuses the slices of the previous frame.
+func groupUpdatedRectanglesByColor(g *Game) [][]Rect {
+	if len(g.rectanglesByColor) != paletteSize() {
+		g.rectanglesByColor = make([][]Rect, paletteSize())
+	}
+	rectanglesByColor := g.rectanglesByColor
+	for i := range rectanglesByColor {
+		rectanglesByColor[i] = rectanglesByColor[i][:0]
+	}
+	width := g.world.Width()
+	height := g.world.Height()
+	for y := 0; y < height; y++ {
+		posX := 0
+		rectWidth := 0
+		col := -1
+		for x := 0; x < width; x++ {
+			if g.world.Dirty(x, y) || updateAllCells || showTemperature || redrawAll {
+				cellCol := cellColorIndex(g.world.Get(x, y))
+				if rectWidth > 0 && cellCol == col {
+					rectWidth++
+					continue
+				}
+				if rectWidth > 0 {
+					rectanglesByColor[col] = append(rectanglesByColor[col], Rect{x: posX, y: y, w: rectWidth, h: 1})
 				}
-				g.grid[y][x].isActive = false
-				pointsByColor[g.grid[y][x].color][y][x] = true
+				posX = x
+				rectWidth = 1
+				col = cellCol
+			} else if rectWidth > 0 {
+				rectanglesByColor[col] = append(rectanglesByColor[col], Rect{x: posX, y: y, w: rectWidth, h: 1})
+				rectWidth = 0
 			}
 		}
+		if rectWidth > 0 {
+			rectanglesByColor[col] = append(rectanglesByColor[col], Rect{x: posX, y: y, w: rectWidth, h: 1})
+		}
 	}
-	return pointsByColor
+	g.world.ClearDirty()
+	redrawAll = false
+	return rectanglesByColor
 }
 
 func drawBrushSize(screen *ebiten.Image, g *Game) {
 	if isChangingBrush {
 		op := &ebiten.DrawImageOptions{}
-		op.GeoM.Translate(float64((50-g.brushSize)*cellSize), float64((50-g.brushSize)*cellSize))
+		centerX := g.world.Width() / 2
+		centerY := g.world.Height() / 2
+		op.GeoM.Translate(float64((centerX-g.brushSize)*cellSize), float64((centerY-g.brushSize)*cellSize))
 		rect := ebiten.NewImage((g.brushSize*2+1)*cellSize, (g.brushSize*2+1)*cellSize)
 		rect.Fill(color.RGBA{R: 255, G: 255, B: 255, A: 255})
 		screen.DrawImage(rect, op)
@@ -99,18 +161,32 @@ func drawBrushSize(screen *ebiten.Image, g *Game) {
 	}
 }
 
+// drawAwakeChunks outlines the chunks processed by the last tick.
+func drawAwakeChunks(screen *ebiten.Image, g *Game) {
+	if !showAwakeChunks {
+		return
+	}
+	chunkPixels := float32(sim.ChunkSize * cellSize)
+	for cy := 0; cy < g.world.ChunksHeight(); cy++ {
+		for cx := 0; cx < g.world.ChunksWidth(); cx++ {
+			if g.world.ChunkAwake(cx, cy) {
+				vector.StrokeRect(screen, float32(cx)*chunkPixels, float32(cy)*chunkPixels, chunkPixels, chunkPixels, 1, color.RGBA{R: 255, A: 255}, false)
+			}
+		}
+	}
+}
+
 func drawCells(g *Game, screen *ebiten.Image) {
-	updatedCellsByColor := groupUpdatedCellsByColor(g)
-	rectanglesByColor := groupRectanglesHorizontallyByColor(updatedCellsByColor)
+	rectanglesByColor := groupUpdatedRectanglesByColor(g)
 	drawRectangles(rectanglesByColor, screen)
 }
 
-func drawRectangles(rectanglesByColor map[color.Color][]Rect, screenBufferImg *ebiten.Image) {
+func drawRectangles(rectanglesByColor [][]Rect, screenBufferImg *ebiten.Image) {
 	op := &ebiten.DrawImageOptions{}
 	for col, rects := range rectanglesByColor {
 		for _, rectangle := range rects {
 			rect := getRectImageByWidth(rectangle.w)
-			rect.Fill(col)
+			rect.Fill(paletteColor(col))
 			op.GeoM.Reset()
 			op.GeoM.Translate(float64(rectangle.x*cellSize), float64(rectangle.y*cellSize))
 			screenBufferImg.DrawImage(rect, op)
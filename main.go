@@ -8,30 +8,49 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"go_project/sim"
 	"image"
 	"image/color"
 	"log"
 	"os"
+	"runtime"
+	"time"
 )
 
 const (
-	screenWidth  = 500
-	screenHeight = 500
-	menuWidth    = 100
-	cellSize     = 5
-	gridSize     = screenWidth / cellSize
+	menuWidth  = 200
+	menuHeight = 800
 )
 
 type Game struct {
-	grid             [gridSize][gridSize]Cell
+	world            *sim.World
 	ui               *ebitenui.UI
-	selectedCellType CellType
-	pixelsToDraw     map[color.Color][][]bool
-	brushSize        int
-	screenBuffer     *image.RGBA
+	selectedCellType sim.CellType
+	// rectanglesByColor is reused from one frame to the next, see
+	// groupUpdatedRectanglesByColor.
+	rectanglesByColor [][]Rect
+	brushSize         int
+	screenBuffer      *image.RGBA
+	// detonator makes a click set off an explosion of explosionPower instead
+	// of painting cells.
+	detonator      bool
+	explosionPower int
+	// facing is the direction the painted gates face. toggling is set from
+	// the click on a switch until the mouse button is released, so that the
+	// click does not paint.
+	facing   sim.Direction
+	toggling bool
 }
 
 var benchmarkMode = false
+var cellSize = 5
+var worldWidth = 100
+var worldHeight = 100
+var scenePath = ""
+var elementsPath = ""
+var seed int64 = 0
+var workers = runtime.NumCPU()
+var seedSet = false
 var countUpdate = 0
 var isChangingBrush = false
 var changingBrushTime = 0
@@ -47,7 +66,7 @@ type resources struct {
 
 func (g *Game) Update() error {
 	g.ui.Update()
-	processCellsPhysic(g)
+	g.world.Tick()
 	handleClick(g)
 	return nil
 }
@@ -60,7 +79,7 @@ func benchmarkCheck() {
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
-	createScreenBufferImgIfNotExist()
+	createScreenBufferImgIfNotExist(g)
 	if onlyShowUpdatedCells {
 		drawCells(g, screen)
 	} else {
@@ -70,6 +89,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	if !onlyShowUpdatedCells {
 		screen.DrawImage(screenBufferImg, op)
 	}
+	drawAwakeChunks(screen, g)
 	drawBrushSize(screen, g)
 	g.ui.Draw(screen)
 	ebiten.SetVsyncEnabled(false)
@@ -79,22 +99,31 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	}
 }
 
-func createScreenBufferImgIfNotExist() {
+func createScreenBufferImgIfNotExist(g *Game) {
 	if screenBufferImg == nil {
-		screenBufferImg = ebiten.NewImage(screenWidth, screenHeight)
+		screenBufferImg = ebiten.NewImage(g.screenWidth(), g.world.Height()*cellSize)
 		screenBufferImg.Fill(color.RGBA{})
 	}
 }
 
 func (g *Game) Layout(_, _ int) (int, int) {
-	return screenWidth + menuWidth, screenHeight
+	return g.screenWidth() + menuWidth, g.screenHeight()
+}
+
+// screenWidth is the width in pixels of the world, without the menu.
+func (g *Game) screenWidth() int {
+	return g.world.Width() * cellSize
+}
+
+func (g *Game) screenHeight() int {
+	return max(g.world.Height()*cellSize, menuHeight)
 }
 
 func main() {
 	initFlags()
-	initWindow()
-	initCellsTypes()
+	initElements()
 	game := getGame()
+	initWindow(game)
 	setupUI(game)
 	if err := ebiten.RunGame(game); err != nil {
 		log.Fatal(err)
@@ -102,45 +131,91 @@ func main() {
 }
 
 func getGame() *Game {
+	world := loadWorld()
+	world.SetWorkers(workers)
+	// Sand is selected at startup, or Air if the elements file has none
+	selected, _ := sim.ElementByName("Sand")
 	return &Game{
-		grid:             initGrid(),
-		pixelsToDraw:     make(map[color.Color][][]bool),
-		selectedCellType: Sand,
+		world:            world,
+		selectedCellType: selected,
 		brushSize:        0,
+		explosionPower:   8,
+		facing:           sim.Right,
 	}
 }
 
 func initFlags() {
 	benchmarkModeUnparsed := flag.Bool("benchmark", false, "benchmark mode")
+	flag.IntVar(&worldWidth, "width", worldWidth, "world width in cells")
+	flag.IntVar(&worldHeight, "height", worldHeight, "world height in cells")
+	flag.IntVar(&cellSize, "cellsize", cellSize, "size of a cell in pixels")
+	flag.StringVar(&scenePath, "scene", scenePath, "scene file to load at startup and to save to")
+	flag.StringVar(&elementsPath, "elements", elementsPath, "JSON file defining the elements (built-in elements if not set)")
+	flag.Int64Var(&seed, "seed", seed, "random seed of the simulation (random if not set, 0 in benchmark mode)")
+	flag.IntVar(&workers, "workers", workers, "number of goroutines running the physics")
 	flag.Parse()
 	benchmarkMode = *benchmarkModeUnparsed
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			seedSet = true
+		}
+	})
+	if !seedSet && !benchmarkMode {
+		seed = time.Now().UnixNano()
+	}
+	if worldWidth <= 0 || worldHeight <= 0 || cellSize <= 0 {
+		log.Fatalf("invalid world size %dx%d with cell size %d", worldWidth, worldHeight, cellSize)
+	}
+}
+
+// initElements loads the elements file given with -elements.
+func initElements() {
+	if elementsPath == "" {
+		return
+	}
+	if err := sim.LoadElements(elementsPath); err != nil {
+		log.Fatal(err)
+	}
 }
 
-func initWindow() {
-	ebiten.SetWindowSize(screenWidth+menuWidth, screenHeight)
+func initWindow(g *Game) {
+	ebiten.SetWindowSize(g.screenWidth()+menuWidth, g.screenHeight())
 	ebiten.SetWindowTitle("sandgox")
 }
 
-// Remaining original code (initGrid, cell types, physics) unchanged...
-func initGrid() [gridSize][gridSize]Cell {
-	grid := [gridSize][gridSize]Cell{}
-	for y := 0; y < gridSize; y++ {
-		for x := 0; x < gridSize; x++ {
-			if benchmarkMode && y < 10 {
-				grid[y][x] = NewSandCell()
-			} else if benchmarkMode && y > 80 && x > 40 && x < 60 {
-				grid[y][x] = NewWaterCell()
-			} else if benchmarkMode && y == 50 && x > 20 && x < 40 {
-				grid[y][x] = NewMetalCell()
-			} else if benchmarkMode && y == 50 && x > 60 && x < 95 {
-				grid[y][x] = NewBlackHoleCell()
-			} else if benchmarkMode && y == 30 && x > 74 && x < 78 {
-				grid[y][x] = NewWaterGeneratorCell()
-			} else {
-				grid[y][x] = NewAirCell()
+// loadWorld opens the scene file if there is one, otherwise it creates a new
+// world from the command-line flags.
+func loadWorld() *sim.World {
+	if scenePath != "" {
+		if _, err := os.Stat(scenePath); err == nil {
+			scene, err := sim.ReadScene(scenePath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if seedSet {
+				scene.Seed = seed
+			}
+			world, err := sim.NewWorldFromScene(scene)
+			if err != nil {
+				log.Fatal(err)
 			}
+			log.Println("seed", world.Seed())
+			return world
 		}
+	}
+	log.Println("seed", seed)
+	return initWorld()
+}
 
+// initWorld creates the world. In benchmark mode it is filled with the
+// benchmark scene, see sim.NewBenchmarkWorld.
+func initWorld() *sim.World {
+	if !benchmarkMode {
+		return sim.NewWorld(worldWidth, worldHeight, seed)
+	}
+	world, err := sim.NewBenchmarkWorld(worldWidth, worldHeight, seed)
+	if err != nil {
+		log.Fatal(err)
 	}
-	return grid
+	return world
 }
@@ -0,0 +1,52 @@
+package sim
+
+// AcidPhysic dissolves a neighbour of the acid, then makes it flow like water.
+func AcidPhysic(x int, y int, w *World) {
+	if corrode(w, x, y) {
+		return
+	}
+	WaterPhysic(x, y, w)
+}
+
+// corrode attacks one of the solids, powders and liquids around the acid at
+// (x, y), picked at random. It dissolves with a chance in percent of 100 minus
+// its corrosion resistance, turning into the corrosionProduct of the acid,
+// which may then be used up. It returns whether the acid was used up.
+func corrode(w *World, x int, y int) bool {
+	acid := w.Get(x, y)
+	var candidates moves
+	for offsetY := -1; offsetY <= 1; offsetY++ {
+		for offsetX := -1; offsetX <= 1; offsetX++ {
+			targetX := x + offsetX
+			targetY := y + offsetY
+			if (offsetX == 0 && offsetY == 0) || !w.InBounds(targetX, targetY) {
+				continue
+			}
+			target := w.Get(targetX, targetY)
+			data := &CellsTypes[target.Type]
+			if target.Type != acid.Type && data.state != Gas && data.state != Energy && data.corrosionResistance < 100 {
+				candidates.x[candidates.n] = targetX
+				candidates.y[candidates.n] = targetY
+				candidates.n++
+			}
+		}
+	}
+	if candidates.n == 0 {
+		return false
+	}
+	// stay awake while there is something left to dissolve
+	w.wakeAround(x, y)
+	rng := w.randAt(x, y)
+	i := rng.Intn(candidates.n)
+	targetX, targetY := candidates.x[i], candidates.y[i]
+	if rng.Intn(100) < CellsTypes[w.Get(targetX, targetY).Type].corrosionResistance {
+		return false
+	}
+	data := &CellsTypes[acid.Type]
+	w.Set(targetX, targetY, newCell(data.corrosionProduct, rng))
+	if rng.Intn(100) < data.corrosionCost {
+		w.Set(x, y, NewAirCell())
+		return true
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package sim
+
+import "testing"
+
+func TestAcidEatsMetalButNotGlass(t *testing.T) {
+	w := NewWorld(64, 64, 1)
+	metal := element(t, "Metal")
+	glass := element(t, "Glass")
+	blackHole := element(t, "Black Hole")
+	fill(w, 0, 40, 63, 40, metal)
+	fill(w, 0, 50, 63, 50, glass)
+	// away from the glass, which the black hole would swallow
+	w.Set(5, 47, w.NewCell(blackHole))
+	acid := element(t, "Acid")
+	fumes := element(t, "Fumes")
+	fill(w, 10, 20, 29, 39, acid)
+	for range 2000 {
+		w.Tick()
+	}
+	holes := 0
+	for x := range w.width {
+		if w.Get(x, 40).Type != metal {
+			holes++
+		}
+		if w.Get(x, 50).Type != glass {
+			t.Fatalf("the acid ate the glass at (%d, 50)", x)
+		}
+	}
+	if holes == 0 {
+		t.Fatal("the acid did not eat into the metal")
+	}
+	through := 0
+	for y := 41; y < 50; y++ {
+		for x := range w.width {
+			if cellType := w.Get(x, y).Type; cellType == acid || cellType == fumes {
+				through++
+			}
+		}
+	}
+	if through == 0 {
+		t.Fatal("no acid went through the metal")
+	}
+	if w.Get(5, 47).Type != blackHole {
+		t.Fatal("the acid ate the black hole")
+	}
+}
@@ -0,0 +1,38 @@
+package sim
+
+import "fmt"
+
+// NewBenchmarkWorld creates a world filled with the benchmark scene: a layer
+// of sand falling on a metal shelf and a line of black holes, a pool of water
+// and a water generator. The scene is laid out for 100×100 cells and
+// stretched to the world size.
+func NewBenchmarkWorld(width int, height int, seed int64) (*World, error) {
+	var elements [5]CellType
+	for i, name := range []string{"Sand", "Water", "Metal", "Black Hole", "Water Generator"} {
+		cellType, ok := ElementByName(name)
+		if !ok {
+			return nil, fmt.Errorf("the benchmark scene needs the element %q", name)
+		}
+		elements[i] = cellType
+	}
+	sand, water, metal, blackHole, waterGenerator := elements[0], elements[1], elements[2], elements[3], elements[4]
+	w := NewWorld(width, height, seed)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			sceneX := x * 100 / width
+			sceneY := y * 100 / height
+			if sceneY < 10 {
+				w.Set(x, y, w.NewCell(sand))
+			} else if sceneY > 80 && sceneX > 40 && sceneX < 60 {
+				w.Set(x, y, w.NewCell(water))
+			} else if sceneY == 50 && sceneX > 20 && sceneX < 40 {
+				w.Set(x, y, w.NewCell(metal))
+			} else if sceneY == 50 && sceneX > 60 && sceneX < 95 {
+				w.Set(x, y, w.NewCell(blackHole))
+			} else if sceneY == 30 && sceneX > 74 && sceneX < 78 {
+				w.Set(x, y, w.NewCell(waterGenerator))
+			}
+		}
+	}
+	return w, nil
+}
@@ -0,0 +1,45 @@
+package sim
+
+import (
+	"fmt"
+	"testing"
+)
+
+// BenchmarkTick measures a tick of the benchmark scene on a 500×500 world
+// for several numbers of workers, to show how the physics scales. A tick
+// must not allocate.
+func BenchmarkTick(b *testing.B) {
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			w, err := NewBenchmarkWorld(500, 500, 0)
+			if err != nil {
+				b.Fatal(err)
+			}
+			w.SetWorkers(workers)
+			defer w.Close()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				w.Tick()
+			}
+		})
+	}
+}
+
+func TestTickDoesNotAllocate(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		w, err := NewBenchmarkWorld(100, 100, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.SetWorkers(workers)
+		// let the first ticks grow the slices the world reuses
+		for range 10 {
+			w.Tick()
+		}
+		if allocs := testing.AllocsPerRun(100, w.Tick); allocs != 0 {
+			t.Errorf("%d workers: %v allocations per tick, expected 0", workers, allocs)
+		}
+		w.Close()
+	}
+}
@@ -0,0 +1,425 @@
+package sim
+
+import (
+	"image/color"
+)
+
+// CellsTypes holds the data of every element, indexed by CellType. It is
+// loaded from the elements file, see LoadElements.
+var CellsTypes = []CellData{}
+
+// OnlyOneColor makes new cells always use the first colour of their element.
+var OnlyOneColor = false
+
+// CellType is the index of an element in CellsTypes.
+type CellType uint8
+
+// Air is the first element of every elements file, empty cells are made of it.
+const Air CellType = 0
+
+// State is the state of matter of an element. It decides how its cells move
+// and what they can move through.
+type State uint8
+
+const (
+	// StaticSolid cells never move and nothing moves through them.
+	StaticSolid State = iota
+	// Powder cells fall and pile up, sinking through lighter liquids.
+	Powder
+	// Liquid cells fall and spread sideways, sinking through lighter liquids.
+	Liquid
+	// Gas cells rise through denser gases and liquids.
+	Gas
+	// Energy cells, such as fire, only move through gases and let powders
+	// and liquids fall through them.
+	Energy
+)
+
+// Cell is kept to a few bytes so that large grids stay cache friendly. Its
+// colour is looked up from the palette of its element.
+type Cell struct {
+	Type CellType
+	// Variant is the index of the cell's colour in the element palette.
+	Variant uint8
+	// Flags holds per-cell boolean state owned by the element's physics.
+	Flags uint8
+	// VelY is the speed of the cell along gravity and VelX its speed across
+	// it, see velocityScale.
+	VelX int8
+	VelY int8
+	// Data is a per-cell value owned by the element's physics, such as the
+	// water held by a plant. Like every field it moves with the cell when
+	// switchPlace swaps it with another.
+	Data uint8
+	// Temp is the temperature of the cell in degrees Celsius.
+	Temp int16
+	// Life is the number of ticks left before the cell expires, 0 for the
+	// elements that last forever.
+	Life uint16
+}
+
+type CellData struct {
+	name   string
+	physic func(x int, y int, w *World)
+	state  State
+	// density orders the fluids and powders that can sink through each other.
+	density int
+	// gravity is the percentage of the world gravity felt by the element,
+	// 100 for powders and liquids unless the elements file says otherwise.
+	gravity int
+	colors  []color.RGBA
+	// temperature is the temperature of new cells, ambientTemperature when
+	// zero.
+	temperature int
+	// heatCapacity is how much heat it takes to warm the element, relative to
+	// Air. It is 1 when zero.
+	heatCapacity int
+	// conductivity is the percentage of the temperature difference with a
+	// neighbour exchanged at each tick. It is limited to maxConductivity.
+	conductivity int
+	// minLife and maxLife bound the lifetime in ticks of new cells, which
+	// never expire when maxLife is zero. Expired cells turn into expireInto.
+	minLife    int
+	maxLife    int
+	expireInto CellType
+	// colorByAge picks the colour of a cell from its age rather than at
+	// random, from the first colour to the last.
+	colorByAge bool
+	// flammability is the chance in percent that a fire or a burning
+	// neighbour sets the element on fire at each tick. A burning cell lasts
+	// between minBurnTime and maxBurnTime ticks, giving off flame, then turns
+	// into burnInto. No flame is given off when it is Keep.
+	flammability int
+	minBurnTime  int
+	maxBurnTime  int
+	burnInto     CellType
+	flame        CellType
+	// viscosity is the chance in percent that a liquid does not spread
+	// sideways during a tick.
+	viscosity int
+	// flicker is the chance in percent that a cell picks another of its
+	// colours at each tick, to make it glow.
+	flicker int
+	// ignites sets the flammable neighbours of the element on fire.
+	ignites bool
+	// extinguishes puts out the burning cells next to the element.
+	extinguishes bool
+	// conducts lets sparks run through the element, see Charged. powers
+	// makes it a power source charging the conductors around it at each
+	// tick.
+	conducts bool
+	powers   bool
+	// delay is the number of extra ticks a spark lingers in the element.
+	delay int
+	// toggles makes the element a switch, which only conducts when the
+	// brush turned it on, see World.Toggle.
+	toggles bool
+	// soil, water and growsInto make a seed grow into a plant when it lies
+	// on soil next to water. Plants drink water and turn into withersInto
+	// when they have none left.
+	soil        CellType
+	water       CellType
+	growsInto   CellType
+	withersInto CellType
+	// gate is the logic of the gate elements, see runGate.
+	gate gate
+	// sensor makes the element power the conductors around it while it
+	// touches an element of the senses type.
+	sensor bool
+	senses CellType
+	// corrosionResistance is the chance in percent that the element resists
+	// each attack of an acid, which never dissolves it at 100. An acid turns
+	// what it dissolves into its corrosionProduct and is used up with a
+	// chance in percent of corrosionCost.
+	corrosionResistance int
+	corrosionProduct    CellType
+	corrosionCost       int
+	// blastResistance is the explosion force the element withstands, see
+	// World.blast. explosion is the power of the explosion of the element
+	// when it is set on fire or destroyed by another explosion.
+	blastResistance int
+	explosion       int
+	// phaseChanges turn the element into another one past some temperatures.
+	phaseChanges []PhaseChange
+	// reactions turn the element and its neighbours into other elements
+	// when they touch.
+	reactions []Reaction
+	// paletteOffset is the index of the first colour of the element in the
+	// palette shared by every element.
+	paletteOffset int
+}
+
+// palette holds the colours of every element one after the other.
+var palette []color.RGBA
+
+// PaletteSize returns the number of colours of all the elements.
+func PaletteSize() int {
+	return len(palette)
+}
+
+func PaletteColor(index int) color.RGBA {
+	return palette[index]
+}
+
+// PaletteIndex returns the index of the cell's colour in the palette.
+func (c Cell) PaletteIndex() int {
+	switch {
+	case c.Flags&Charged != 0:
+		return sparkPaletteOffset
+	case c.Flags&Discharged != 0:
+		return sparkPaletteOffset + 1
+	}
+	return CellsTypes[c.Type].paletteOffset + int(c.Variant)
+}
+
+func (c Cell) Color() color.RGBA {
+	return palette[c.PaletteIndex()]
+}
+
+// newCell creates a cell of an element at its starting temperature, with one
+// of its colours picked at random and its lifetime if it has one.
+func newCell(cellType CellType, rng *Rand) Cell {
+	data := &CellsTypes[cellType]
+	cell := Cell{
+		Type:    cellType,
+		Variant: randomVariant(cellType, rng),
+		Temp:    int16(data.startTemperature()),
+		Life:    data.newLife(rng),
+	}
+	if data.colorByAge {
+		cell.Variant = cell.ageVariant()
+	}
+	if data.toggles {
+		cell.setSwitch(false)
+	}
+	return cell
+}
+
+func (d CellData) startTemperature() int {
+	if d.temperature == 0 {
+		return ambientTemperature
+	}
+	return d.temperature
+}
+
+// randomVariant picks one of the colours of an element. When rng is nil the
+// first colour is used.
+func randomVariant(cellType CellType, rng *Rand) uint8 {
+	if len(CellsTypes[cellType].colors) == 1 || rng == nil {
+		return 0
+	}
+	index := rng.Intn(len(CellsTypes[cellType].colors))
+	if OnlyOneColor {
+		index = 0
+	}
+	return uint8(index)
+}
+
+// processChunkPhysic runs the physics of one chunk, the even rows from top to
+// bottom, then the odd rows from bottom to top, so that each cell runs once.
+// Each row is processed even cells first from left to right, then odd cells
+// from right to left.
+func processChunkPhysic(w *World, chunk int) {
+	w.chunkRands[chunk].Seed(uint64(w.seed) ^ uint64(w.generation)<<32 ^ uint64(chunk)*0x9e3779b97f4a7c15)
+	startX := (chunk % w.chunksWidth) * ChunkSize
+	startY := (chunk / w.chunksWidth) * ChunkSize
+	endX := min(startX+ChunkSize, w.width)
+	endY := min(startY+ChunkSize, w.height)
+	xBStart := startX + lastOddIndex(endX-startX)
+	yBStart := startY + lastOddIndex(endY-startY)
+	for yA := startY; yA < endY; yA += 2 {
+		processRowPhysic(w, yA, startX, endX, xBStart)
+	}
+
+	for yB := yBStart; yB > startY; yB -= 2 {
+		processRowPhysic(w, yB, startX, endX, xBStart)
+	}
+
+	active := processChunkReactions(w, startX, startY, endX, endY)
+	powered := processChunkElectricity(w, startX, startY, endX, endY)
+	if processChunkLifetime(w, startX, startY, endX, endY) || active || powered {
+		w.wakeNext[chunk].Store(true)
+	}
+	if processChunkHeat(w, startX, startY, endX, endY) {
+		w.wakeAround(startX, startY)
+		w.wakeAround(endX-1, endY-1)
+	}
+}
+
+func processRowPhysic(w *World, y int, startX int, endX int, xBStart int) {
+	for xA := startX; xA < endX; xA += 2 {
+		cellA := w.Get(xA, y)
+		CellsTypes[cellA.Type].physic(xA, y, w)
+	}
+
+	for xB := xBStart; xB > startX; xB -= 2 {
+		cellB := w.Get(xB, y)
+		CellsTypes[cellB.Type].physic(xB, y, w)
+	}
+}
+
+// lastOddIndex returns the highest odd index in a row of n cells.
+func lastOddIndex(n int) int {
+	if n%2 == 0 {
+		return n - 1
+	}
+	return n - 2
+}
+
+// canSwitch tells whether the cell at (x, y) may swap with the one at
+// (targetX, targetY) during the current tick.
+func (w *World) canSwitch(x int, y int, targetX int, targetY int) bool {
+	return !w.hasMoved(x, y) && !w.hasMoved(targetX, targetY) && w.Get(x, y).canSwitchWith(w.Get(targetX, targetY))
+}
+
+// moves is a fixed-size list of candidate destinations for a cell, so the
+// physics can pick one at random without allocating.
+type moves struct {
+	x [8]int
+	y [8]int
+	n int
+}
+
+// addIf adds (targetX, targetY) to the candidates if the cell at (x, y) can
+// move there.
+func (m *moves) addIf(w *World, x int, y int, targetX int, targetY int) {
+	if w.InBounds(targetX, targetY) && w.canSwitch(x, y, targetX, targetY) {
+		m.x[m.n] = targetX
+		m.y[m.n] = targetY
+		m.n++
+	}
+}
+
+// addTowards adds the neighbour in the direction of gravity turned by steps
+// eighths of a turn, see World.towards.
+func (m *moves) addTowards(w *World, x int, y int, steps int) {
+	targetX, targetY := w.towards(x, y, steps)
+	m.addIf(w, x, y, targetX, targetY)
+}
+
+// addGasTowards is like addTowards but only accepts moves into other gases, so
+// that a gas drifting sideways does not wander through liquids.
+func (m *moves) addGasTowards(w *World, x int, y int, steps int) {
+	targetX, targetY := w.towards(x, y, steps)
+	if w.InBounds(targetX, targetY) && CellsTypes[w.Get(targetX, targetY).Type].state == Gas {
+		m.addIf(w, x, y, targetX, targetY)
+	}
+}
+
+// addSpreadTowards is like addTowards but refuses to push aside a liquid that
+// the cell above it could sink into, so that a liquid spreading sideways does
+// not keep stirring a lighter one instead of letting it rise.
+func (m *moves) addSpreadTowards(w *World, x int, y int, steps int) {
+	targetX, targetY := w.towards(x, y, steps)
+	if !w.InBounds(targetX, targetY) {
+		return
+	}
+	target := w.Get(targetX, targetY)
+	if aboveX, aboveY := w.towards(targetX, targetY, 4); CellsTypes[target.Type].state == Liquid && w.InBounds(aboveX, aboveY) && w.Get(aboveX, aboveY).canSwitchWith(target) {
+		return
+	}
+	m.addIf(w, x, y, targetX, targetY)
+}
+
+// apply moves the cell at (x, y) to one of the candidates picked at random.
+func (m *moves) apply(w *World, x int, y int) {
+	if m.n == 0 {
+		return
+	}
+	i := 0
+	if m.n > 1 {
+		i = w.randAt(x, y).Intn(m.n)
+	}
+	switchPlace(x, y, m.x[i], m.y[i], w)
+}
+
+func (origin Cell) canSwitchWith(target Cell) bool {
+	if target.Type == origin.Type {
+		return false
+	}
+	dataOrigin := CellsTypes[origin.Type]
+	dataTarget := CellsTypes[target.Type]
+	switch dataOrigin.state {
+	case Powder, Liquid:
+		// powders and liquids sink through lighter liquids
+		targetIsLighter := dataTarget.density < dataOrigin.density
+		return dataTarget.state == Gas || dataTarget.state == Energy || (dataTarget.state == Liquid && targetIsLighter)
+	case Gas:
+		// buoyancy: a gas takes the place of denser gases and liquids
+		targetIsDenser := dataTarget.density > dataOrigin.density
+		return (dataTarget.state == Gas || dataTarget.state == Liquid) && targetIsDenser
+	case Energy:
+		return dataTarget.state == Gas
+	}
+	return false
+}
+
+func SandPhysic(x int, y int, w *World) {
+	if fall(w, x, y) || slide(w, x, y) || !w.feelsGravity(w.Get(x, y).Type) {
+		return
+	}
+	var candidates moves
+	candidates.addTowards(w, x, y, 1)
+	candidates.addTowards(w, x, y, -1)
+	candidates.apply(w, x, y)
+}
+
+func switchPlace(Ax int, Ay int, Bx int, By int, w *World) {
+	cellA := w.Get(Ax, Ay)
+	cellB := w.Get(Bx, By)
+	w.Set(Bx, By, cellA)
+	w.Set(Ax, Ay, cellB)
+}
+
+func WaterPhysic(x int, y int, w *World) {
+	if fall(w, x, y) || slide(w, x, y) || !w.feelsGravity(w.Get(x, y).Type) {
+		return
+	}
+	if belowX, belowY := w.towards(x, y, 0); w.InBounds(belowX, belowY) {
+		var candidates moves
+		candidates.addTowards(w, x, y, 1)
+		candidates.addTowards(w, x, y, -1)
+		if candidates.n == 0 {
+			candidates.addSpreadTowards(w, x, y, 2)
+			candidates.addSpreadTowards(w, x, y, -2)
+			// viscous liquids only spread sideways some of the time
+			if viscosity := CellsTypes[w.Get(x, y).Type].viscosity; candidates.n > 0 && w.randAt(x, y).Intn(100) < viscosity {
+				w.wakeAround(x, y)
+				return
+			}
+		}
+		candidates.apply(w, x, y)
+	}
+}
+
+// GasPhysic makes a gas rise against gravity and spread sideways into other
+// gases. Without gravity, and some of the time under weak gravity, it drifts
+// in any direction.
+func GasPhysic(x int, y int, w *World) {
+	var candidates moves
+	if !w.buoyant(x, y) {
+		for turn := 0; turn < 8; turn++ {
+			candidates.addGasTowards(w, x, y, turn)
+		}
+	} else {
+		candidates.addTowards(w, x, y, 4)
+		candidates.addTowards(w, x, y, 3)
+		candidates.addTowards(w, x, y, -3)
+		candidates.addGasTowards(w, x, y, 2)
+		candidates.addGasTowards(w, x, y, -2)
+	}
+	candidates.apply(w, x, y)
+}
+
+// NewCell creates a cell of the given type, drawing its colour from the
+// world's random generator.
+func (w *World) NewCell(cellType CellType) Cell {
+	return newCell(cellType, w.rng)
+}
+
+func NewAirCell() Cell {
+	return newCell(Air, nil)
+}
+
+func NoPhysic(int, int, *World) {
+}
@@ -0,0 +1,77 @@
+package sim
+
+import "testing"
+
+func TestOilFloatsOnWater(t *testing.T) {
+	w := NewWorld(16, 32, 1)
+	oil := element(t, "Oil")
+	water := element(t, "Water")
+	// the oil starts under the water
+	fill(w, 0, 24, 15, 31, oil)
+	fill(w, 0, 16, 15, 23, water)
+	for range 1000 {
+		w.Tick()
+	}
+	lowestOil, highestWater := -1, w.height
+	for i, cell := range w.cells {
+		y := i / w.width
+		switch cell.Type {
+		case oil:
+			lowestOil = max(lowestOil, y)
+		case water:
+			highestWater = min(highestWater, y)
+		}
+	}
+	if lowestOil >= highestWater {
+		t.Fatalf("oil down to row %d, water up to row %d: the oil does not float", lowestOil, highestWater)
+	}
+}
+
+func TestGunpowderPilesLikePowder(t *testing.T) {
+	w := NewWorld(32, 32, 1)
+	gunpowder := element(t, "Gunpowder")
+	fill(w, 16, 0, 16, 19, gunpowder)
+	for range 500 {
+		w.Tick()
+	}
+	heights := make([]int, w.width)
+	for x := range w.width {
+		for y := w.height - 1; y >= 0 && w.Get(x, y).Type == gunpowder; y-- {
+			heights[x]++
+		}
+		// nothing floats above the pile
+		for y := w.height - 1 - heights[x]; y >= 0; y-- {
+			if w.Get(x, y).Type == gunpowder {
+				t.Fatalf("gunpowder hanging at (%d, %d)", x, y)
+			}
+		}
+	}
+	total := 0
+	for x := range w.width {
+		total += heights[x]
+		if x > 0 && abs(heights[x]-heights[x-1]) > 1 {
+			t.Fatalf("the pile is too steep between columns %d and %d: %v", x-1, x, heights)
+		}
+	}
+	if total != 20 || heights[16] < 2 {
+		t.Fatalf("expected a pile of 20 grains around column 16, got %v", heights)
+	}
+}
+
+func TestLitGunpowderExplodes(t *testing.T) {
+	w := NewWorld(48, 48, 1)
+	gunpowder := element(t, "Gunpowder")
+	fill(w, 0, 47, 47, 47, element(t, "Metal"))
+	// two trails with a gap that fire cannot cross but a blast can
+	fill(w, 4, 46, 11, 46, gunpowder)
+	fill(w, 16, 46, 23, 46, gunpowder)
+	w.Set(3, 46, w.NewCell(element(t, "Fire")))
+	for range 10 {
+		w.Tick()
+	}
+	for i, cell := range w.cells {
+		if cell.Type == gunpowder {
+			t.Fatalf("gunpowder left at (%d, %d)", i%w.width, i/w.width)
+		}
+	}
+}
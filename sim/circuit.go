@@ -0,0 +1,140 @@
+package sim
+
+// gate is the logic of the gate elements, which read the sparks of the
+// conductors next to them and charge the conductor they face.
+type gate uint8
+
+const (
+	noGate gate = iota
+	// diodeGate passes the sparks coming from behind it, never the ones
+	// coming from the front.
+	diodeGate
+	// notGate sparks while no spark comes from behind it.
+	notGate
+	// andGate sparks while sparks come from both its sides, orGate while
+	// they come from either of them.
+	andGate
+	orGate
+)
+
+// gates are the gates an element can be, by their name in the elements file.
+var gates = map[string]gate{
+	"diode": diodeGate,
+	"not":   notGate,
+	"and":   andGate,
+	"or":    orGate,
+}
+
+// The two bits from facingShift in Cell.Flags hold the direction a gate
+// faces: 0 for Right, then clockwise.
+const (
+	facingShift       = 3
+	facingMask  uint8 = 3 << facingShift
+)
+
+// Facing returns the direction the cell faces, Right, Down, Left or Up. Only
+// gates use it.
+func (c Cell) Facing() Direction {
+	return Right.Rotate(int(c.Flags&facingMask>>facingShift) * 2)
+}
+
+// SetFacing turns the cell towards a direction, rounded down to Right, Down,
+// Left or Up.
+func (c *Cell) SetFacing(direction Direction) {
+	if direction == NoDirection {
+		return
+	}
+	quarter := uint8((int(direction)-int(Right)+8)%8) / 2
+	c.Flags = c.Flags&^facingMask | quarter<<facingShift
+}
+
+func (c Cell) counter() int {
+	return int(c.Flags & counterMask >> counterShift)
+}
+
+func (c *Cell) setCounter(counter int) {
+	c.Flags = c.Flags&^counterMask | uint8(counter)<<counterShift&counterMask
+}
+
+// NewCellFacing creates a cell like NewCell, turned towards direction when its
+// element is a gate.
+func (w *World) NewCellFacing(cellType CellType, direction Direction) Cell {
+	cell := w.NewCell(cellType)
+	if CellsTypes[cellType].gate != noGate {
+		cell.SetFacing(direction)
+	}
+	return cell
+}
+
+// runGate charges the conductor in front of the gate at (x, y) when its
+// inputs allow it. Diodes and NOT gates read the conductor behind them, AND
+// and OR gates the ones on their sides.
+//
+// An input is on while its conductor is charged or discharged, and during the
+// tick after, so that the pulses of a battery, one every three ticks, make a
+// steady signal.
+func runGate(w *World, x int, y int) {
+	i := y*w.width + x
+	cell := w.cells[i]
+	logic := CellsTypes[cell.Type].gate
+	facing := cell.Facing()
+	inputs := [2]Direction{facing.Rotate(4), NoDirection}
+	if logic == andGate || logic == orGate {
+		inputs = [2]Direction{facing.Rotate(-2), facing.Rotate(2)}
+	}
+	seen := 0
+	for input, direction := range inputs {
+		if direction == NoDirection {
+			continue
+		}
+		offsetX, offsetY := direction.Vector()
+		if sparked(w, x+offsetX, y+offsetY) {
+			seen |= 1 << input
+		}
+	}
+	on := seen | cell.counter()
+	var output bool
+	switch logic {
+	case diodeGate:
+		output = on != 0
+	case notGate:
+		output = on == 0
+	case andGate:
+		output = on == 3
+	case orGate:
+		output = on != 0
+	}
+	if output {
+		offsetX, offsetY := facing.Vector()
+		charge(w, x+offsetX, y+offsetY)
+	}
+	w.cells[i].setCounter(seen)
+}
+
+// sensing tells whether the sensor at (x, y) touches the element it senses.
+func sensing(w *World, x int, y int) bool {
+	return touches(w, x, y, CellsTypes[w.Get(x, y).Type].senses)
+}
+
+// Toggle turns the switch at (x, y) on or off. It returns false when the cell
+// is not a switch.
+func (w *World) Toggle(x int, y int) bool {
+	cell := w.Get(x, y)
+	if !CellsTypes[cell.Type].toggles {
+		return false
+	}
+	cell.setSwitch(cell.Flags&SwitchedOn == 0)
+	w.Set(x, y, cell)
+	return true
+}
+
+// setSwitch turns a switch on or off, showing its first colour when off and
+// its second one when on.
+func (c *Cell) setSwitch(on bool) {
+	c.Flags &^= SwitchedOn
+	c.Variant = 0
+	if on {
+		c.Flags |= SwitchedOn
+		c.Variant = 1
+	}
+}
@@ -0,0 +1,170 @@
+package sim
+
+import (
+	"fmt"
+	"testing"
+)
+
+// The circuits of these tests have a gate at (10, 10) and an output wire of
+// metal from x = 11 to x = 20 on its row, read at (18, 10).
+const (
+	gateX   = 10
+	gateY   = 10
+	outputX = 18
+)
+
+// pulses ticks the world and counts the ticks during which the cell at (x, y)
+// is charged.
+func pulses(w *World, x int, y int, ticks int) int {
+	count := 0
+	for range ticks {
+		w.Tick()
+		if w.Get(x, y).Flags&Charged != 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// powerFrom lays a metal wire from the gate to a battery placed eight cells
+// away in a direction.
+func powerFrom(t *testing.T, w *World, direction Direction) {
+	offsetX, offsetY := direction.Vector()
+	metal := element(t, "Metal")
+	for step := 1; step < 8; step++ {
+		w.Set(gateX+offsetX*step, gateY+offsetY*step, w.NewCell(metal))
+	}
+	w.Set(gateX+offsetX*8, gateY+offsetY*8, w.NewCell(element(t, "Battery")))
+}
+
+func TestGates(t *testing.T) {
+	tests := []struct {
+		gate   string
+		inputs []Direction
+		output bool
+	}{
+		{"Diode", nil, false},
+		{"Diode", []Direction{Left}, true},
+		{"Not Gate", nil, true},
+		{"Not Gate", []Direction{Left}, false},
+		{"And Gate", nil, false},
+		{"And Gate", []Direction{Up}, false},
+		{"And Gate", []Direction{Down}, false},
+		{"And Gate", []Direction{Up, Down}, true},
+		{"Or Gate", nil, false},
+		{"Or Gate", []Direction{Up}, true},
+		{"Or Gate", []Direction{Down}, true},
+		{"Or Gate", []Direction{Up, Down}, true},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s%v", test.gate, test.inputs), func(t *testing.T) {
+			w := NewWorld(32, 32, 1)
+			w.Set(gateX, gateY, w.NewCellFacing(element(t, test.gate), Right))
+			fill(w, gateX+1, gateY, 20, gateY, element(t, "Metal"))
+			for _, input := range test.inputs {
+				powerFrom(t, w, input)
+			}
+			// let the signals settle before reading the output
+			pulses(w, outputX, gateY, 30)
+			if got := pulses(w, outputX, gateY, 60) > 0; got != test.output {
+				t.Fatalf("output %v, expected %v", got, test.output)
+			}
+		})
+	}
+}
+
+func TestDiodeFacings(t *testing.T) {
+	for _, facing := range []Direction{Right, Left} {
+		t.Run(fmt.Sprint(facing), func(t *testing.T) {
+			// the battery is behind the diode, the other wire in front of it
+			behindX, _ := facing.Rotate(4).Vector()
+			frontX := gateX - behindX*8
+			w := NewWorld(32, 32, 1)
+			w.Set(gateX, gateY, w.NewCellFacing(element(t, "Diode"), facing))
+			powerFrom(t, w, facing.Rotate(4))
+			fill(w, min(gateX-behindX, frontX), gateY, max(gateX-behindX, frontX), gateY, element(t, "Metal"))
+			if pulses(w, frontX, gateY, 60) == 0 {
+				t.Fatal("the diode does not pass the sparks from behind it")
+			}
+
+			w = NewWorld(32, 32, 1)
+			w.Set(gateX, gateY, w.NewCellFacing(element(t, "Diode"), facing.Rotate(4)))
+			powerFrom(t, w, facing.Rotate(4))
+			fill(w, min(gateX-behindX, frontX), gateY, max(gateX-behindX, frontX), gateY, element(t, "Metal"))
+			if pulses(w, frontX, gateY, 60) != 0 {
+				t.Fatal("the diode passes the sparks from its front")
+			}
+		})
+	}
+}
+
+// arrival charges the start of a wire from x = 2 to x = 18 on row 10 and
+// returns the tick at which the spark reaches its end.
+func arrival(t *testing.T, middle string) int {
+	w := NewWorld(32, 32, 1)
+	fill(w, 2, 10, 18, 10, element(t, "Metal"))
+	w.Set(10, 10, w.NewCell(element(t, middle)))
+	start := w.Get(2, 10)
+	start.Flags |= Charged
+	w.Set(2, 10, start)
+	for tick := 1; tick <= 100; tick++ {
+		w.Tick()
+		if w.Get(18, 10).Flags&Charged != 0 {
+			return tick
+		}
+	}
+	t.Fatalf("the spark never went through the %s", middle)
+	return 0
+}
+
+func TestDelayHoldsTheSpark(t *testing.T) {
+	delay := CellsTypes[element(t, "Delay")].delay
+	if got, want := arrival(t, "Delay"), arrival(t, "Metal")+delay; got != want {
+		t.Fatalf("the spark arrived at tick %d, expected %d", got, want)
+	}
+}
+
+func TestSwitchConductsWhenOn(t *testing.T) {
+	w := NewWorld(32, 32, 1)
+	powerFrom(t, w, Left)
+	w.Set(gateX, gateY, w.NewCell(element(t, "Switch")))
+	fill(w, gateX+1, gateY, 20, gateY, element(t, "Metal"))
+	if pulses(w, outputX, gateY, 60) != 0 {
+		t.Fatal("the switch conducts while off")
+	}
+	if !w.Toggle(gateX, gateY) {
+		t.Fatal("the switch did not toggle")
+	}
+	if pulses(w, outputX, gateY, 60) == 0 {
+		t.Fatal("the switch does not conduct while on")
+	}
+	w.Toggle(gateX, gateY)
+	pulses(w, outputX, gateY, 10)
+	if pulses(w, outputX, gateY, 60) != 0 {
+		t.Fatal("the switch conducts after being turned off")
+	}
+	if w.Toggle(outputX, gateY) {
+		t.Fatal("toggled a metal cell")
+	}
+}
+
+func TestWaterSensor(t *testing.T) {
+	// the sensor lies on a glass floor, with a wire on its right and room
+	// for one row of water on its left
+	w := NewWorld(32, 16, 1)
+	fill(w, 0, 15, 31, 15, element(t, "Glass"))
+	w.Set(5, 14, w.NewCell(element(t, "Water Sensor")))
+	fill(w, 6, 14, 20, 14, element(t, "Metal"))
+	if pulses(w, 18, 14, 60) != 0 {
+		t.Fatal("the sensor fires while dry")
+	}
+	fill(w, 0, 14, 4, 14, element(t, "Water"))
+	if pulses(w, 18, 14, 60) == 0 {
+		t.Fatal("the sensor does not fire while wet")
+	}
+	fill(w, 0, 14, 4, 14, Air)
+	pulses(w, 18, 14, 10)
+	if pulses(w, 18, 14, 60) != 0 {
+		t.Fatal("the sensor fires once dry again")
+	}
+}
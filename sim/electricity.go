@@ -0,0 +1,140 @@
+package sim
+
+import "image/color"
+
+const (
+	// Charged is set in Cell.Flags while a spark runs through a conductive
+	// cell. At the next tick the spark moves on to the idle conductive
+	// neighbours and the cell becomes Discharged.
+	Charged uint8 = 1 << 1
+	// Discharged is set in Cell.Flags during the tick after a spark, while
+	// the cell cannot be charged again. It keeps sparks from going back the
+	// way they came.
+	Discharged uint8 = 1 << 2
+	// SwitchedOn is set in Cell.Flags while a switch conducts.
+	SwitchedOn uint8 = 1 << 7
+)
+
+// The two bits from counterShift in Cell.Flags hold the ticks a spark still
+// lingers in a delay cell, and the inputs a gate saw at the previous tick.
+const (
+	counterShift       = 5
+	counterMask  uint8 = 3 << counterShift
+	maxCellDelay       = 3
+)
+
+// sparkColors are the colours of the Charged and Discharged cells, whatever
+// their element. They are added after the colours of the elements in the
+// palette, starting at sparkPaletteOffset.
+var sparkColors = []color.RGBA{
+	{255, 245, 160, 255},
+	{80, 120, 255, 255},
+}
+
+var sparkPaletteOffset int
+
+// processChunkElectricity moves the sparks of the area one cell forward and
+// lets the power sources charge the conductors around them. It returns
+// whether any cell carries a spark or powers, in which case the chunk must
+// stay awake.
+//
+// A cell changed during the current tick is left alone, so a spark moves by
+// exactly one cell per tick whatever the order the chunks are processed in.
+func processChunkElectricity(w *World, startX int, startY int, endX int, endY int) bool {
+	active := false
+	for y := startY; y < endY; y++ {
+		for x := startX; x < endX; x++ {
+			cell := w.Get(x, y)
+			data := &CellsTypes[cell.Type]
+			switch {
+			case data.powers:
+				chargeAround(w, x, y)
+				active = true
+			case data.sensor:
+				if sensing(w, x, y) {
+					chargeAround(w, x, y)
+					active = true
+				}
+			case data.gate != noGate:
+				runGate(w, x, y)
+				active = true
+			case cell.Flags&(Charged|Discharged) == 0:
+			case w.hasMoved(x, y):
+				active = true
+			case cell.Flags&Charged != 0:
+				if delay := cell.counter(); delay > 0 {
+					// the spark lingers in delay cells
+					w.cells[y*w.width+x].setCounter(delay - 1)
+				} else {
+					spark(w, x, y)
+				}
+				active = true
+			default:
+				cell.Flags &^= Discharged
+				w.Set(x, y, cell)
+			}
+		}
+	}
+	return active
+}
+
+// spark runs the spark of the cell at (x, y): it charges the conductive
+// neighbours, sets the flammable ones on fire, and leaves the cell
+// discharged.
+func spark(w *World, x int, y int) {
+	chargeAround(w, x, y)
+	igniteAround(w, x, y)
+	cell := w.Get(x, y)
+	cell.Flags = cell.Flags&^Charged | Discharged
+	w.Set(x, y, cell)
+}
+
+// chargeAround charges the conductive neighbours of (x, y), see charge. Sparks
+// only reach the four neighbours sharing a side with the cell, so that wires
+// touching by a corner, like the inputs and the output of a gate, stay apart.
+func chargeAround(w *World, x int, y int) {
+	charge(w, x+1, y)
+	charge(w, x-1, y)
+	charge(w, x, y+1)
+	charge(w, x, y-1)
+}
+
+// charge starts a spark in the cell at (x, y) if it conducts, is idle and did
+// not change during the current tick.
+func charge(w *World, x int, y int) {
+	if !w.InBounds(x, y) {
+		return
+	}
+	cell := w.Get(x, y)
+	if !cell.conducts() || cell.Flags&(Charged|Discharged) != 0 || w.hasMoved(x, y) {
+		return
+	}
+	cell.Flags |= Charged
+	cell.setCounter(CellsTypes[cell.Type].delay)
+	w.Set(x, y, cell)
+}
+
+// conducts tells whether sparks can run through the cell. Switches only
+// conduct when they are on.
+func (c Cell) conducts() bool {
+	data := &CellsTypes[c.Type]
+	return data.conducts && (!data.toggles || c.Flags&SwitchedOn != 0)
+}
+
+// sparked tells whether the cell at (x, y) carried a spark, charged or
+// discharged, at the start of the current tick. The conductors that changed
+// during the tick are one step ahead: a charged one was idle, a discharged
+// one was charged and an idle one was discharged.
+func sparked(w *World, x int, y int) bool {
+	if !w.InBounds(x, y) {
+		return false
+	}
+	cell := w.Get(x, y)
+	if !CellsTypes[cell.Type].conducts {
+		return false
+	}
+	if w.hasMoved(x, y) {
+		return cell.Flags&Charged == 0
+	}
+	return cell.Flags&(Charged|Discharged) != 0
+}
@@ -0,0 +1,496 @@
+package sim
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+)
+
+// defaultElements is the definition of the built-in elements, used unless
+// LoadElements is called.
+//
+//go:embed elements.json
+var defaultElements []byte
+
+// behaviours are the physics an element can use, by their name in the
+// elements file.
+var behaviours = map[string]func(x int, y int, w *World){
+	"none":   NoPhysic,
+	"powder": SandPhysic,
+	"liquid": WaterPhysic,
+	"gas":    GasPhysic,
+	"fire":   FirePhysic,
+	"acid":   AcidPhysic,
+	"seed":   SeedPhysic,
+	"plant":  PlantPhysic,
+}
+
+var states = map[string]State{
+	"static_solid": StaticSolid,
+	"powder":       Powder,
+	"liquid":       Liquid,
+	"gas":          Gas,
+	"energy":       Energy,
+}
+
+// elementsByName gives the CellType of each element from its name.
+var elementsByName = map[string]CellType{}
+
+// elementsFile is the on-disk representation of the elements. The first one
+// must be Air, the element empty cells are made of.
+type elementsFile struct {
+	Elements []elementDefinition `json:"elements"`
+}
+
+type elementDefinition struct {
+	Name                string                  `json:"name"`
+	State               string                  `json:"state"`
+	Behaviour           string                  `json:"behaviour"`
+	Density             int                     `json:"density"`
+	Gravity             *int                    `json:"gravity"`
+	Viscosity           int                     `json:"viscosity"`
+	Temperature         int                     `json:"temperature"`
+	HeatCapacity        int                     `json:"heat_capacity"`
+	Conductivity        int                     `json:"conductivity"`
+	Colors              []string                `json:"colors"`
+	Flicker             int                     `json:"flicker"`
+	Lifetime            []int                   `json:"lifetime"`
+	ExpireInto          string                  `json:"expire_into"`
+	ColorByAge          bool                    `json:"color_by_age"`
+	Flammability        int                     `json:"flammability"`
+	BurnTime            []int                   `json:"burn_time"`
+	BurnInto            string                  `json:"burn_into"`
+	Flame               string                  `json:"flame"`
+	Ignites             bool                    `json:"ignites"`
+	Extinguishes        bool                    `json:"extinguishes"`
+	Conducts            bool                    `json:"conducts"`
+	Powers              bool                    `json:"powers"`
+	Delay               int                     `json:"delay"`
+	Switch              bool                    `json:"switch"`
+	Gate                string                  `json:"gate"`
+	Senses              string                  `json:"senses"`
+	Soil                string                  `json:"soil"`
+	Water               string                  `json:"water"`
+	GrowsInto           string                  `json:"grows_into"`
+	WithersInto         string                  `json:"withers_into"`
+	CorrosionResistance int                     `json:"corrosion_resistance"`
+	CorrosionProduct    string                  `json:"corrosion_product"`
+	CorrosionCost       int                     `json:"corrosion_cost"`
+	BlastResistance     int                     `json:"blast_resistance"`
+	Explosion           int                     `json:"explosion"`
+	PhaseChanges        []phaseChangeDefinition `json:"phase_changes"`
+	Reactions           []reactionDefinition    `json:"reactions"`
+}
+
+type phaseChangeDefinition struct {
+	Above       bool   `json:"above"`
+	Temperature int    `json:"temperature"`
+	Into        string `json:"into"`
+}
+
+// reactionDefinition is a Reaction with elements given by name. An empty
+// into or with_into keeps the cell, a missing probability means 100.
+type reactionDefinition struct {
+	With        string `json:"with"`
+	Probability *int   `json:"probability"`
+	Into        string `json:"into"`
+	WithInto    string `json:"with_into"`
+	Explosion   int    `json:"explosion"`
+}
+
+func init() {
+	if err := setElements(defaultElements); err != nil {
+		panic("built-in elements: " + err.Error())
+	}
+}
+
+// LoadElements replaces the elements by the ones defined in a JSON file. It
+// must be called before creating any world.
+func LoadElements(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := setElements(data); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// ElementByName returns the CellType of the element with the given name.
+func ElementByName(name string) (CellType, bool) {
+	cellType, ok := elementsByName[name]
+	return cellType, ok
+}
+
+func (t CellType) String() string {
+	switch {
+	case t == Any:
+		return "Any"
+	case t == Keep:
+		return "Keep"
+	case int(t) < len(CellsTypes):
+		return CellsTypes[t].name
+	}
+	return fmt.Sprintf("CellType(%d)", t)
+}
+
+func setElements(data []byte) error {
+	elements, names, err := parseElements(data)
+	if err != nil {
+		return err
+	}
+	CellsTypes = elements
+	elementsByName = names
+	defaultFireball = Keep
+	if fire, ok := names["Fire"]; ok {
+		defaultFireball = fire
+	}
+	palette = palette[:0]
+	for i := range CellsTypes {
+		CellsTypes[i].paletteOffset = len(palette)
+		palette = append(palette, CellsTypes[i].colors...)
+	}
+	sparkPaletteOffset = len(palette)
+	palette = append(palette, sparkColors...)
+	return nil
+}
+
+func parseElements(data []byte) ([]CellData, map[string]CellType, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var file elementsFile
+	if err := decoder.Decode(&file); err != nil {
+		return nil, nil, err
+	}
+	if len(file.Elements) == 0 {
+		return nil, nil, errors.New("no elements defined")
+	}
+	if len(file.Elements) > int(Keep) {
+		return nil, nil, fmt.Errorf("%d elements defined, at most %d are allowed", len(file.Elements), Keep)
+	}
+	if file.Elements[0].Name != "Air" {
+		return nil, nil, fmt.Errorf("the first element must be \"Air\", not %q", file.Elements[0].Name)
+	}
+	names := make(map[string]CellType, len(file.Elements))
+	for i, definition := range file.Elements {
+		switch {
+		case definition.Name == "":
+			return nil, nil, fmt.Errorf("element %d: missing name", i)
+		case definition.Name == "Any" || definition.Name == "Keep":
+			return nil, nil, fmt.Errorf("element %d: %q is a reserved name", i, definition.Name)
+		}
+		if _, exists := names[definition.Name]; exists {
+			return nil, nil, fmt.Errorf("element %d: %q is defined twice", i, definition.Name)
+		}
+		names[definition.Name] = CellType(i)
+	}
+	elements := make([]CellData, len(file.Elements))
+	for i, definition := range file.Elements {
+		data, err := definition.cellData(names)
+		if err != nil {
+			return nil, nil, fmt.Errorf("element %d (%q): %w", i, definition.Name, err)
+		}
+		elements[i] = data
+	}
+	return elements, names, nil
+}
+
+func (d elementDefinition) cellData(names map[string]CellType) (CellData, error) {
+	data := CellData{
+		name:                d.Name,
+		density:             d.Density,
+		temperature:         d.Temperature,
+		heatCapacity:        d.HeatCapacity,
+		conductivity:        d.Conductivity,
+		extinguishes:        d.Extinguishes,
+		conducts:            d.Conducts,
+		powers:              d.Powers,
+		delay:               d.Delay,
+		toggles:             d.Switch,
+		viscosity:           d.Viscosity,
+		flicker:             d.Flicker,
+		ignites:             d.Ignites,
+		blastResistance:     d.BlastResistance,
+		corrosionResistance: d.CorrosionResistance,
+		corrosionCost:       d.CorrosionCost,
+		explosion:           d.Explosion,
+	}
+	state, ok := states[d.State]
+	if !ok {
+		return data, fmt.Errorf("unknown state %q", d.State)
+	}
+	data.state = state
+	behaviour := d.Behaviour
+	if behaviour == "" {
+		behaviour = "none"
+	}
+	if data.physic, ok = behaviours[behaviour]; !ok {
+		return data, fmt.Errorf("unknown behaviour %q", d.Behaviour)
+	}
+	// powders and liquids fall under normal gravity unless told otherwise
+	switch {
+	case d.Gravity != nil:
+		data.gravity = *d.Gravity
+	case state == Powder || state == Liquid:
+		data.gravity = 100
+	}
+	if data.gravity < 0 {
+		return data, fmt.Errorf("negative gravity %d", data.gravity)
+	}
+	if d.Temperature < minTemperature || d.Temperature > maxTemperature {
+		return data, fmt.Errorf("temperature %d out of range [%d, %d]", d.Temperature, minTemperature, maxTemperature)
+	}
+	if d.HeatCapacity < 0 {
+		return data, fmt.Errorf("negative heat capacity %d", d.HeatCapacity)
+	}
+	if d.Conductivity < 0 || d.Conductivity > 100 {
+		return data, fmt.Errorf("conductivity %d out of range [0, 100]", d.Conductivity)
+	}
+	if d.Viscosity < 0 || d.Viscosity > 100 {
+		return data, fmt.Errorf("viscosity %d out of range [0, 100]", d.Viscosity)
+	}
+	if d.Flicker < 0 || d.Flicker > 100 {
+		return data, fmt.Errorf("flicker %d out of range [0, 100]", d.Flicker)
+	}
+	if err := data.setCircuit(d, names); err != nil {
+		return data, err
+	}
+	if err := data.setGrowth(d, behaviour, names); err != nil {
+		return data, err
+	}
+	if d.CorrosionResistance < 0 || d.CorrosionResistance > 100 {
+		return data, fmt.Errorf("corrosion resistance %d out of range [0, 100]", d.CorrosionResistance)
+	}
+	if d.CorrosionCost < 0 || d.CorrosionCost > 100 {
+		return data, fmt.Errorf("corrosion cost %d out of range [0, 100]", d.CorrosionCost)
+	}
+	data.corrosionProduct = Air
+	if d.CorrosionProduct != "" {
+		if data.corrosionProduct, ok = names[d.CorrosionProduct]; !ok {
+			return data, fmt.Errorf("unknown corrosion product %q", d.CorrosionProduct)
+		}
+	}
+	if d.BlastResistance < 0 {
+		return data, fmt.Errorf("negative blast resistance %d", d.BlastResistance)
+	}
+	if d.Explosion < 0 || d.Explosion > MaxExplosionPower {
+		return data, fmt.Errorf("explosion %d out of range [0, %d]", d.Explosion, MaxExplosionPower)
+	}
+	if len(d.Colors) == 0 || len(d.Colors) > 256 {
+		return data, fmt.Errorf("%d colours given, expected between 1 and 256", len(d.Colors))
+	}
+	for _, hex := range d.Colors {
+		col, err := parseColor(hex)
+		if err != nil {
+			return data, err
+		}
+		data.colors = append(data.colors, col)
+	}
+	if err := data.setLifetime(d, names); err != nil {
+		return data, err
+	}
+	if err := data.setCombustion(d, names); err != nil {
+		return data, err
+	}
+	for _, change := range d.PhaseChanges {
+		into, ok := names[change.Into]
+		if !ok {
+			return data, fmt.Errorf("phase change into unknown element %q", change.Into)
+		}
+		data.phaseChanges = append(data.phaseChanges, PhaseChange{Above: change.Above, Temperature: change.Temperature, Into: into})
+	}
+	for _, definition := range d.Reactions {
+		reaction, err := definition.reaction(names)
+		if err != nil {
+			return data, err
+		}
+		data.reactions = append(data.reactions, reaction)
+	}
+	return data, nil
+}
+
+// setLifetime reads the lifetime, given as [min, max] ticks, and the element
+// expired cells turn into, Air by default.
+func (data *CellData) setLifetime(d elementDefinition, names map[string]CellType) error {
+	if d.Lifetime == nil {
+		if d.ExpireInto != "" || d.ColorByAge {
+			return errors.New("expire_into and color_by_age need a lifetime")
+		}
+		return nil
+	}
+	if len(d.Lifetime) != 2 || d.Lifetime[0] < 1 || d.Lifetime[0] > d.Lifetime[1] || d.Lifetime[1] > maxLife {
+		return fmt.Errorf("invalid lifetime %v, expected [min, max] with 1 <= min <= max <= %d", d.Lifetime, maxLife)
+	}
+	data.minLife = d.Lifetime[0]
+	data.maxLife = d.Lifetime[1]
+	data.colorByAge = d.ColorByAge
+	if d.ExpireInto == "" {
+		data.expireInto = Air
+		return nil
+	}
+	var ok bool
+	if data.expireInto, ok = names[d.ExpireInto]; !ok {
+		return fmt.Errorf("expires into unknown element %q", d.ExpireInto)
+	}
+	return nil
+}
+
+// setCombustion reads how the element burns. Burning cells turn into Air and
+// give off Fire by default.
+func (data *CellData) setCombustion(d elementDefinition, names map[string]CellType) error {
+	if d.Flammability == 0 {
+		if d.BurnTime != nil || d.BurnInto != "" || d.Flame != "" {
+			return errors.New("burn_time, burn_into and flame need a flammability")
+		}
+		return nil
+	}
+	if d.Flammability < 0 || d.Flammability > 100 {
+		return fmt.Errorf("flammability %d out of range [0, 100]", d.Flammability)
+	}
+	if len(d.BurnTime) != 2 || d.BurnTime[0] < 1 || d.BurnTime[0] > d.BurnTime[1] || d.BurnTime[1] > maxLife {
+		return fmt.Errorf("invalid burn_time %v, expected [min, max] with 1 <= min <= max <= %d", d.BurnTime, maxLife)
+	}
+	data.flammability = d.Flammability
+	data.minBurnTime = d.BurnTime[0]
+	data.maxBurnTime = d.BurnTime[1]
+	data.burnInto = Air
+	if d.BurnInto != "" {
+		var ok bool
+		if data.burnInto, ok = names[d.BurnInto]; !ok {
+			return fmt.Errorf("burns into unknown element %q", d.BurnInto)
+		}
+	}
+	data.flame = Keep
+	switch fire, hasFire := names["Fire"]; {
+	case d.Flame == "Keep":
+	case d.Flame != "":
+		var ok bool
+		if data.flame, ok = names[d.Flame]; !ok {
+			return fmt.Errorf("unknown flame element %q", d.Flame)
+		}
+	case hasFire:
+		data.flame = fire
+	}
+	return nil
+}
+
+// setGrowth reads the elements seeds and plants live on. Seeds need a soil,
+// water and the element they grow into, plants need water and wither into Air
+// by default.
+func (data *CellData) setGrowth(d elementDefinition, behaviour string, names map[string]CellType) error {
+	seed := behaviour == "seed"
+	plant := behaviour == "plant"
+	switch {
+	case seed && (d.Soil == "" || d.Water == "" || d.GrowsInto == ""):
+		return errors.New("the seed behaviour needs soil, water and grows_into")
+	case plant && d.Water == "":
+		return errors.New("the plant behaviour needs water")
+	case !seed && (d.Soil != "" || d.GrowsInto != ""), !seed && !plant && d.Water != "", !plant && d.WithersInto != "":
+		return errors.New("soil, water, grows_into and withers_into are only used by the seed and plant behaviours")
+	}
+	fields := []struct {
+		name     string
+		value    string
+		cellType *CellType
+	}{
+		{"soil", d.Soil, &data.soil},
+		{"water", d.Water, &data.water},
+		{"grows_into", d.GrowsInto, &data.growsInto},
+		{"withers_into", d.WithersInto, &data.withersInto},
+	}
+	for _, field := range fields {
+		if field.value == "" {
+			continue
+		}
+		var ok bool
+		if *field.cellType, ok = names[field.value]; !ok {
+			return fmt.Errorf("%s unknown element %q", field.name, field.value)
+		}
+	}
+	return nil
+}
+
+// setCircuit reads the role of the element in electric circuits.
+func (data *CellData) setCircuit(d elementDefinition, names map[string]CellType) error {
+	roles := 0
+	for _, role := range []bool{d.Conducts, d.Powers, d.Gate != "", d.Senses != ""} {
+		if role {
+			roles++
+		}
+	}
+	if roles > 1 {
+		return errors.New("an element can only be one of a conductor, a power source, a gate or a sensor")
+	}
+	if d.Delay < 0 || d.Delay > maxCellDelay {
+		return fmt.Errorf("delay %d out of range [0, %d]", d.Delay, maxCellDelay)
+	}
+	if (d.Delay > 0 || d.Switch) && !d.Conducts {
+		return errors.New("delay and switch need conducts")
+	}
+	if d.Switch && len(d.Colors) != 2 {
+		return fmt.Errorf("%d colours given for a switch, expected 2: off and on", len(d.Colors))
+	}
+	if d.Gate != "" {
+		var ok bool
+		if data.gate, ok = gates[d.Gate]; !ok {
+			return fmt.Errorf("unknown gate %q", d.Gate)
+		}
+	}
+	if d.Senses != "" {
+		var ok bool
+		if data.senses, ok = names[d.Senses]; !ok {
+			return fmt.Errorf("senses unknown element %q", d.Senses)
+		}
+		data.sensor = true
+	}
+	return nil
+}
+
+func (d reactionDefinition) reaction(names map[string]CellType) (Reaction, error) {
+	reaction := Reaction{Probability: 100, Into: Keep, WithInto: Keep}
+	if d.Probability != nil {
+		reaction.Probability = *d.Probability
+	}
+	if reaction.Probability < 1 || reaction.Probability > 100 {
+		return reaction, fmt.Errorf("reaction with %q: probability %d out of range [1, 100]", d.With, reaction.Probability)
+	}
+	if d.Explosion < 0 || d.Explosion > MaxExplosionPower {
+		return reaction, fmt.Errorf("reaction with %q: explosion %d out of range [0, %d]", d.With, d.Explosion, MaxExplosionPower)
+	}
+	reaction.Explosion = d.Explosion
+	var ok bool
+	if d.With == "Any" {
+		reaction.With = Any
+	} else if reaction.With, ok = names[d.With]; !ok {
+		return reaction, fmt.Errorf("reaction with unknown element %q", d.With)
+	}
+	if d.Into != "" && d.Into != "Keep" {
+		if reaction.Into, ok = names[d.Into]; !ok {
+			return reaction, fmt.Errorf("reaction with %q into unknown element %q", d.With, d.Into)
+		}
+	}
+	if d.WithInto != "" && d.WithInto != "Keep" {
+		if reaction.WithInto, ok = names[d.WithInto]; !ok {
+			return reaction, fmt.Errorf("reaction with %q turns it into unknown element %q", d.With, d.WithInto)
+		}
+	}
+	return reaction, nil
+}
+
+// parseColor parses a colour written as #rrggbb.
+func parseColor(hex string) (color.RGBA, error) {
+	var col color.RGBA
+	if len(hex) != 7 {
+		return col, fmt.Errorf("invalid colour %q, expected #rrggbb", hex)
+	}
+	if _, err := fmt.Sscanf(hex, "#%02x%02x%02x", &col.R, &col.G, &col.B); err != nil {
+		return col, fmt.Errorf("invalid colour %q, expected #rrggbb", hex)
+	}
+	col.A = 255
+	return col, nil
+}
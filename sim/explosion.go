@@ -0,0 +1,156 @@
+package sim
+
+import (
+	"math"
+	"slices"
+)
+
+// MaxExplosionPower bounds the power of an explosion, which is also its
+// radius in cells.
+const MaxExplosionPower = 32
+
+// explosionSpeed is the velocity, in velocityScale units per tick, given to a
+// cell by one point of blast force.
+const explosionSpeed = 12
+
+// explosion reaches much further than the physics of a cell may, see
+// ChunkSize. Explosions are therefore queued while the chunks are processed
+// and applied one after the other at the end of the tick.
+type explosion struct {
+	x     int
+	y     int
+	power int
+	// fireball is the element the cells near the centre turn into, or Keep
+	// to turn them into Air.
+	fireball CellType
+}
+
+// defaultFireball is the fireball of the explosions that do not come from an
+// element: Fire when the elements file has it.
+var defaultFireball = Keep
+
+// Explode queues an explosion of the given power at (x, y), applied at the end
+// of the current tick, or of the next one when called between ticks.
+func (w *World) Explode(x int, y int, power int) {
+	w.queueExplosion(x, y, power, defaultFireball)
+}
+
+func (w *World) queueExplosion(x int, y int, power int, fireball CellType) {
+	power = min(power, MaxExplosionPower)
+	if power <= 0 {
+		return
+	}
+	w.explosionsLock.Lock()
+	w.explosions = append(w.explosions, explosion{x: x, y: y, power: power, fireball: fireball})
+	w.explosionsLock.Unlock()
+}
+
+// applyExplosions applies the explosions queued during the tick, sorted so
+// that the result does not depend on the order the chunks were processed in.
+// The explosions they trigger are applied at the next tick.
+func (w *World) applyExplosions() {
+	if len(w.explosions) == 0 {
+		return
+	}
+	w.explosions, w.applying = w.applying[:0], w.explosions
+	slices.SortFunc(w.applying, compareExplosions)
+	for _, e := range w.applying {
+		w.blast(e)
+	}
+}
+
+func compareExplosions(a explosion, b explosion) int {
+	switch {
+	case a.y != b.y:
+		return a.y - b.y
+	case a.x != b.x:
+		return a.x - b.x
+	case a.power != b.power:
+		return a.power - b.power
+	}
+	return int(a.fireball) - int(b.fireball)
+}
+
+// blast applies an explosion. Its force decreases from its power at the centre
+// to zero at a distance of power cells. Cells whose blast resistance is below
+// the force are destroyed, explosive ones exploding in turn, and the movable
+// cells that resist are thrown away from the centre. Static solids that
+// resist shield the cells behind them.
+func (w *World) blast(e explosion) {
+	radius := e.power
+	for offsetY := -radius; offsetY <= radius; offsetY++ {
+		for offsetX := -radius; offsetX <= radius; offsetX++ {
+			x := e.x + offsetX
+			y := e.y + offsetY
+			distance := math.Hypot(float64(offsetX), float64(offsetY))
+			if distance > float64(radius) || !w.InBounds(x, y) || w.shielded(e, x, y) {
+				continue
+			}
+			force := e.force(distance)
+			cell := w.Get(x, y)
+			data := &CellsTypes[cell.Type]
+			if data.blastResistance < force {
+				if data.explosion > 0 && cell.Flags&Burning == 0 {
+					w.queueExplosion(x, y, data.explosion, data.flame)
+				}
+				debris := Air
+				if e.fireball != Keep && distance <= float64(radius)/3 {
+					debris = e.fireball
+				}
+				if cell.Type != debris {
+					w.Set(x, y, newCell(debris, w.rng))
+				}
+			} else if (data.state == Powder || data.state == Liquid) && distance > 0 {
+				speed := float64(force * explosionSpeed)
+				w.throw(x, y, float64(offsetX)/distance*speed, float64(offsetY)/distance*speed)
+			}
+		}
+	}
+}
+
+// force is the force of the explosion at some distance from its centre.
+func (e explosion) force(distance float64) int {
+	return e.power - int(distance)
+}
+
+// shielded tells whether a static solid resisting the explosion stands
+// between its centre and (x, y).
+func (w *World) shielded(e explosion, x int, y int) bool {
+	dx := x - e.x
+	dy := y - e.y
+	steps := max(abs(dx), abs(dy))
+	for step := 1; step < steps; step++ {
+		stepX := e.x + int(math.Round(float64(dx*step)/float64(steps)))
+		stepY := e.y + int(math.Round(float64(dy*step)/float64(steps)))
+		data := &CellsTypes[w.Get(stepX, stepY).Type]
+		if data.state != StaticSolid {
+			continue
+		}
+		distance := math.Hypot(float64(stepX-e.x), float64(stepY-e.y))
+		if data.blastResistance >= e.force(distance) {
+			return true
+		}
+	}
+	return false
+}
+
+// throw adds a velocity given in screen coordinates to the cell at (x, y),
+// converting it to the gravity frame of the cells.
+func (w *World) throw(x int, y int, velocityX float64, velocityY float64) {
+	alongX, alongY := w.down().Vector()
+	acrossX, acrossY := w.down().Rotate(2).Vector()
+	along := (velocityX*float64(alongX) + velocityY*float64(alongY)) / float64(alongX*alongX+alongY*alongY)
+	across := (velocityX*float64(acrossX) + velocityY*float64(acrossY)) / float64(acrossX*acrossX+acrossY*acrossY)
+	cell := &w.cells[y*w.width+x]
+	cell.VelY = clampVelocity(int(cell.VelY) + int(along))
+	cell.VelX = clampVelocity(int(cell.VelX) + int(across))
+	w.dirty[y*w.width+x] = true
+	w.wakeAround(x, y)
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
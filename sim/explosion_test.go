@@ -0,0 +1,80 @@
+package sim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBlastForceFallsOffWithDistance(t *testing.T) {
+	w := NewWorld(40, 40, 1)
+	glass := element(t, "Glass")
+	fill(w, 0, 0, 39, 39, glass)
+	w.Explode(20, 20, 10)
+	w.Tick()
+	// glass resists a force of 5, which the blast has 5 cells away
+	for y := range w.height {
+		for x := range w.width {
+			distance := int(math.Hypot(float64(x-20), float64(y-20)))
+			if destroyed := w.Get(x, y).Type != glass; destroyed != (distance < 5) {
+				t.Fatalf("the glass %d cells away is destroyed: %v", distance, destroyed)
+			}
+		}
+	}
+}
+
+func TestBlastResistance(t *testing.T) {
+	w := NewWorld(40, 40, 1)
+	stone := element(t, "Stone")
+	glass := element(t, "Glass")
+	// a force of 7 reaches both
+	w.Set(17, 20, w.NewCell(glass))
+	w.Set(23, 20, w.NewCell(stone))
+	w.Explode(20, 20, 10)
+	w.Tick()
+	if w.Get(17, 20).Type == glass {
+		t.Error("the glass resisted the blast")
+	}
+	if w.Get(23, 20).Type != stone {
+		t.Error("the stone did not resist the blast")
+	}
+}
+
+func TestMetalShieldsBlast(t *testing.T) {
+	w := NewWorld(40, 40, 1)
+	glass := element(t, "Glass")
+	// the same glass on both sides of the centre, only the right one behind
+	// a metal wall
+	w.Set(17, 20, w.NewCell(glass))
+	w.Set(23, 20, w.NewCell(glass))
+	fill(w, 22, 17, 22, 23, element(t, "Metal"))
+	w.Explode(20, 20, 10)
+	w.Tick()
+	if w.Get(17, 20).Type == glass {
+		t.Error("the glass in the open resisted the blast")
+	}
+	if w.Get(23, 20).Type != glass {
+		t.Error("the metal did not shield the glass")
+	}
+}
+
+func TestBlastThrowsMovableCells(t *testing.T) {
+	w := NewWorld(64, 40, 1)
+	sand := element(t, "Sand")
+	fill(w, 0, 39, 63, 39, element(t, "Metal"))
+	// sand resists a force of 6, which the blast has 10 cells away
+	w.Set(22, 38, w.NewCell(sand))
+	w.Set(42, 38, w.NewCell(sand))
+	w.Explode(32, 38, 16)
+	for range 10 {
+		w.Tick()
+	}
+	var thrown []int
+	for i, cell := range w.cells {
+		if cell.Type == sand {
+			thrown = append(thrown, i%w.width)
+		}
+	}
+	if len(thrown) != 2 || thrown[0] >= 22 || thrown[1] <= 42 {
+		t.Fatalf("the sand was not thrown away from the centre: it lies at x = %v", thrown)
+	}
+}
@@ -0,0 +1,98 @@
+package sim
+
+// Burning is set in Cell.Flags while a flammable cell burns. Its Life then
+// counts down the ticks before it turns into the burnInto of its element.
+const Burning uint8 = 1 << 0
+
+// FirePhysic sets the flammable neighbours of a fire on fire and makes it rise
+// like a gas.
+func FirePhysic(x int, y int, w *World) {
+	igniteAround(w, x, y)
+	GasPhysic(x, y, w)
+}
+
+// igniteAround sets each flammable neighbour of (x, y) on fire, with a chance
+// in percent given by the flammability of its element.
+func igniteAround(w *World, x int, y int) {
+	rng := w.randAt(x, y)
+	for offsetY := -1; offsetY <= 1; offsetY++ {
+		for offsetX := -1; offsetX <= 1; offsetX++ {
+			targetX := x + offsetX
+			targetY := y + offsetY
+			if !w.InBounds(targetX, targetY) {
+				continue
+			}
+			target := w.Get(targetX, targetY)
+			flammability := CellsTypes[target.Type].flammability
+			if flammability > 0 && target.Flags&Burning == 0 && rng.Intn(100) < flammability {
+				ignite(w, targetX, targetY)
+			}
+		}
+	}
+}
+
+// ignite sets the cell at (x, y) on fire. Explosive elements explode at the
+// end of the tick.
+func ignite(w *World, x int, y int) {
+	cell := w.Get(x, y)
+	data := &CellsTypes[cell.Type]
+	cell.Flags |= Burning
+	cell.Life = uint16(data.minBurnTime)
+	if data.maxBurnTime > data.minBurnTime {
+		cell.Life += uint16(w.randAt(x, y).Intn(data.maxBurnTime - data.minBurnTime + 1))
+	}
+	w.Set(x, y, cell)
+	if data.explosion > 0 {
+		w.queueExplosion(x, y, data.explosion, data.flame)
+	}
+}
+
+// burn runs one tick of a burning cell: it ignites its flammable neighbours
+// and gives off its flame into a gas around it. It returns false when a
+// neighbour put the fire out.
+func burn(w *World, x int, y int) bool {
+	if extinguishedAround(w, x, y) {
+		cell := w.Get(x, y)
+		cell.Flags &^= Burning
+		cell.Life = CellsTypes[cell.Type].newLife(w.randAt(x, y))
+		w.Set(x, y, cell)
+		return false
+	}
+	igniteAround(w, x, y)
+	flame := CellsTypes[w.Get(x, y).Type].flame
+	if flame == Keep {
+		return true
+	}
+	var candidates moves
+	for offsetY := -1; offsetY <= 1; offsetY++ {
+		for offsetX := -1; offsetX <= 1; offsetX++ {
+			targetX := x + offsetX
+			targetY := y + offsetY
+			if (offsetX != 0 || offsetY != 0) && w.InBounds(targetX, targetY) && CellsTypes[w.Get(targetX, targetY).Type].state == Gas {
+				candidates.x[candidates.n] = targetX
+				candidates.y[candidates.n] = targetY
+				candidates.n++
+			}
+		}
+	}
+	if candidates.n > 0 {
+		rng := w.randAt(x, y)
+		i := rng.Intn(candidates.n)
+		w.Set(candidates.x[i], candidates.y[i], newCell(flame, rng))
+	}
+	return true
+}
+
+// extinguishedAround tells whether a neighbour of (x, y) puts fires out.
+func extinguishedAround(w *World, x int, y int) bool {
+	for offsetY := -1; offsetY <= 1; offsetY++ {
+		for offsetX := -1; offsetX <= 1; offsetX++ {
+			targetX := x + offsetX
+			targetY := y + offsetY
+			if w.InBounds(targetX, targetY) && CellsTypes[w.Get(targetX, targetY).Type].extinguishes {
+				return true
+			}
+		}
+	}
+	return false
+}
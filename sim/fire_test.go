@@ -0,0 +1,32 @@
+package sim
+
+import "testing"
+
+func TestWoodenBoxBurnsDownToAsh(t *testing.T) {
+	w := NewWorld(32, 32, 1)
+	wood := element(t, "Wood")
+	ash := element(t, "Ash")
+	fill(w, 0, 31, 31, 31, element(t, "Metal"))
+	// a hollow box of wood standing on the floor
+	fill(w, 10, 20, 20, 20, wood)
+	fill(w, 10, 30, 20, 30, wood)
+	fill(w, 10, 21, 10, 29, wood)
+	fill(w, 20, 21, 20, 29, wood)
+	// fire trapped inside the box
+	fill(w, 11, 21, 19, 29, element(t, "Fire"))
+	for range 3000 {
+		w.Tick()
+	}
+	ashes := 0
+	for i, cell := range w.cells {
+		switch cell.Type {
+		case wood:
+			t.Fatalf("wood left at (%d, %d)", i%w.width, i/w.width)
+		case ash:
+			ashes++
+		}
+	}
+	if ashes == 0 {
+		t.Fatal("the box left no ash")
+	}
+}
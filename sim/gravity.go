@@ -0,0 +1,125 @@
+package sim
+
+// Direction is one of the eight neighbours of a cell, in clockwise order
+// starting from Down, or NoDirection.
+type Direction uint8
+
+const (
+	Down Direction = iota
+	DownLeft
+	Left
+	UpLeft
+	Up
+	UpRight
+	Right
+	DownRight
+	NoDirection
+)
+
+var directionVectors = [8][2]int{
+	{0, 1},
+	{-1, 1},
+	{-1, 0},
+	{-1, -1},
+	{0, -1},
+	{1, -1},
+	{1, 0},
+	{1, 1},
+}
+
+var directionNames = [9]string{
+	"Down",
+	"Down Left",
+	"Left",
+	"Up Left",
+	"Up",
+	"Up Right",
+	"Right",
+	"Down Right",
+	"Off",
+}
+
+func (d Direction) String() string {
+	return directionNames[d]
+}
+
+// Vector returns the offset to the neighbour in that direction.
+func (d Direction) Vector() (int, int) {
+	if d == NoDirection {
+		return 0, 0
+	}
+	return directionVectors[d][0], directionVectors[d][1]
+}
+
+// Rotate turns the direction by steps eighths of a turn, clockwise for
+// positive steps.
+func (d Direction) Rotate(steps int) Direction {
+	if d == NoDirection {
+		return d
+	}
+	return Direction(((int(d)+steps)%8 + 8) % 8)
+}
+
+// SetGravity sets the direction of gravity, or NoDirection to turn it off,
+// and its strength in percent of the normal gravity.
+func (w *World) SetGravity(direction Direction, strength int) {
+	w.gravity = direction
+	w.gravityStrength = max(strength, 0)
+	for i := range w.wakeNext {
+		w.wakeNext[i].Store(true)
+	}
+}
+
+func (w *World) Gravity() (Direction, int) {
+	return w.gravity, w.gravityStrength
+}
+
+// feelsGravity tells whether gravity pulls the cells of an element at all. It
+// is false when gravity is off, when its strength is 0 or when the element
+// has no gravity, in which case the cells float.
+func (w *World) feelsGravity(cellType CellType) bool {
+	return w.gravity != NoDirection && w.gravityStrength > 0 && CellsTypes[cellType].gravity > 0
+}
+
+// acceleration returns the velocity gained at this tick along gravity by a
+// cell of an element. It is scaled by the strength of gravity and by the
+// gravity of the element, both in percent, and its fractional part is
+// rounded up at random so that weak gravity still accelerates cells on
+// average.
+func (w *World) acceleration(cellType CellType, rng *Rand) int {
+	if !w.feelsGravity(cellType) {
+		return 0
+	}
+	scaled := gravityAcceleration * w.gravityStrength * CellsTypes[cellType].gravity
+	acceleration := scaled / (100 * 100)
+	if remainder := scaled % (100 * 100); remainder > 0 && rng.Intn(100*100) < remainder {
+		acceleration++
+	}
+	return acceleration
+}
+
+// buoyant tells whether a gas at (x, y) rises at this tick. Gases rise at
+// every tick under normal gravity or stronger, some of the ticks under weaker
+// gravity and never without it.
+func (w *World) buoyant(x int, y int) bool {
+	if w.gravity == NoDirection || w.gravityStrength == 0 {
+		return false
+	}
+	return w.gravityStrength >= 100 || w.randAt(x, y).Intn(100) < w.gravityStrength
+}
+
+// down returns the direction cells fall towards. When gravity is off, the
+// sideways velocity of cells is still measured as if it pointed down.
+func (w *World) down() Direction {
+	if w.gravity == NoDirection {
+		return Down
+	}
+	return w.gravity
+}
+
+// towards returns the neighbour of (x, y) in the direction of gravity turned
+// by steps eighths of a turn, see Direction.Rotate.
+func (w *World) towards(x int, y int, steps int) (int, int) {
+	dx, dy := w.down().Rotate(steps).Vector()
+	return x + dx, y + dy
+}
@@ -0,0 +1,68 @@
+package sim
+
+import "testing"
+
+// fallTicks drops a grain of sand from the top of a column of the given
+// height and returns the ticks it takes to reach the floor, or -1 when it
+// has not reached it after limit ticks.
+func fallTicks(t *testing.T, height int, strength int, limit int) int {
+	t.Helper()
+	w := NewWorld(1, height, 1)
+	w.SetGravity(Down, strength)
+	w.Set(0, 0, w.NewCell(element(t, "Sand")))
+	for tick := 1; tick <= limit; tick++ {
+		w.Tick()
+		if w.Get(0, height-1).Type != Air {
+			return tick
+		}
+	}
+	return -1
+}
+
+func TestGravityStrength(t *testing.T) {
+	if ticks := fallTicks(t, 60, 0, 300); ticks != -1 {
+		t.Errorf("sand reached the floor in %d ticks without gravity", ticks)
+	}
+	weak := fallTicks(t, 60, 10, 1000)
+	weaker := fallTicks(t, 60, 5, 1000)
+	normal := fallTicks(t, 60, 100, 1000)
+	strong := fallTicks(t, 60, 300, 1000)
+	if weaker == -1 || weak == -1 || !(weaker > weak && weak > normal && normal > strong) {
+		t.Errorf("fall times at 5%%, 10%%, 100%% and 300%% gravity are %d, %d, %d and %d ticks, expected decreasing times",
+			weaker, weak, normal, strong)
+	}
+}
+
+func TestNoGravityKeepsPowdersInPlace(t *testing.T) {
+	w := NewWorld(16, 16, 1)
+	w.SetGravity(Down, 0)
+	sand := element(t, "Sand")
+	// a tower that would topple under gravity
+	fill(w, 8, 4, 8, 8, sand)
+	fill(w, 0, 15, 15, 15, element(t, "Metal"))
+	for range 100 {
+		w.Tick()
+	}
+	for y := 4; y <= 8; y++ {
+		if w.Get(8, y).Type != sand {
+			t.Fatalf("the sand at (8, %d) moved without gravity", y)
+		}
+	}
+}
+
+func TestPowderGravityDefault(t *testing.T) {
+	elements, names, err := parseElements([]byte(`{"elements": [
+		{"name": "Air", "state": "gas", "colors": ["#000000"]},
+		{"name": "Dust", "state": "powder", "behaviour": "powder", "colors": ["#ffffff"]},
+		{"name": "Feather", "state": "powder", "behaviour": "powder", "gravity": 10, "colors": ["#ffffff"]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gravity := elements[names["Dust"]].gravity; gravity != 100 {
+		t.Errorf("a powder without gravity has gravity %d, expected 100", gravity)
+	}
+	if gravity := elements[names["Feather"]].gravity; gravity != 10 {
+		t.Errorf("gravity 10 was read as %d", gravity)
+	}
+}
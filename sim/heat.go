@@ -0,0 +1,87 @@
+package sim
+
+const (
+	ambientTemperature = 20
+	minTemperature     = -273
+	maxTemperature     = 9999
+	// maxConductivity keeps the heat exchange stable: a cell never gives
+	// more than a quarter of its temperature difference to each neighbour.
+	maxConductivity = 25
+)
+
+// PhaseChange turns a cell into another element when its temperature goes
+// above or below a threshold. The new cell keeps the temperature.
+type PhaseChange struct {
+	// Above is true when the change happens above Temperature, false when it
+	// happens below it.
+	Above       bool
+	Temperature int
+	Into        CellType
+}
+
+// processChunkHeat spreads heat between each cell of the area and its right
+// and bottom neighbours, then applies the phase changes. It returns whether
+// any temperature changed, in which case the chunk and its neighbours must
+// stay awake.
+func processChunkHeat(w *World, startX int, startY int, endX int, endY int) bool {
+	changed := false
+	for y := startY; y < endY; y++ {
+		for x := startX; x < endX; x++ {
+			if x+1 < w.width && exchangeHeat(w, x, y, x+1, y) {
+				changed = true
+			}
+			if y+1 < w.height && exchangeHeat(w, x, y, x, y+1) {
+				changed = true
+			}
+		}
+	}
+	for y := startY; y < endY; y++ {
+		for x := startX; x < endX; x++ {
+			applyPhaseChanges(w, x, y)
+		}
+	}
+	return changed
+}
+
+// exchangeHeat moves heat from the hotter of two cells to the colder one,
+// keeping the total energy, and returns whether their temperatures changed.
+func exchangeHeat(w *World, x int, y int, otherX int, otherY int) bool {
+	cell := &w.cells[y*w.width+x]
+	other := &w.cells[otherY*w.width+otherX]
+	difference := int(cell.Temp) - int(other.Temp)
+	if difference == 0 {
+		return false
+	}
+	data := &CellsTypes[cell.Type]
+	otherData := &CellsTypes[other.Type]
+	conductivity := min(data.conductivity, otherData.conductivity, maxConductivity)
+	capacity := max(data.heatCapacity, 1)
+	otherCapacity := max(otherData.heatCapacity, 1)
+	// each cell changes by the exchanged energy divided by its capacity,
+	// computed directly so that small exchanges are not rounded away twice
+	total := (capacity + otherCapacity) * 50
+	change := difference * conductivity * otherCapacity / total
+	otherChange := difference * conductivity * capacity / total
+	if change == 0 && otherChange == 0 {
+		return false
+	}
+	cell.Temp = clampTemperature(int(cell.Temp) - change)
+	other.Temp = clampTemperature(int(other.Temp) + otherChange)
+	return true
+}
+
+func applyPhaseChanges(w *World, x int, y int) {
+	cell := w.Get(x, y)
+	for _, change := range CellsTypes[cell.Type].phaseChanges {
+		if (change.Above && int(cell.Temp) > change.Temperature) || (!change.Above && int(cell.Temp) < change.Temperature) {
+			changed := newCell(change.Into, w.randAt(x, y))
+			changed.Temp = cell.Temp
+			w.Set(x, y, changed)
+			return
+		}
+	}
+}
+
+func clampTemperature(temperature int) int16 {
+	return int16(max(min(temperature, maxTemperature), minTemperature))
+}
@@ -0,0 +1,67 @@
+package sim
+
+// maxLife is the longest lifetime in ticks an element can give its cells.
+const maxLife = 1<<16 - 1
+
+// newLife draws the lifetime of a new cell of an element, 0 when the element
+// does not expire. When rng is nil the longest lifetime is used.
+func (d CellData) newLife(rng *Rand) uint16 {
+	if d.maxLife == 0 {
+		return 0
+	}
+	if rng == nil || d.minLife == d.maxLife {
+		return uint16(d.maxLife)
+	}
+	return uint16(d.minLife + rng.Intn(d.maxLife-d.minLife+1))
+}
+
+// processChunkLifetime counts down the life of the cells of the area and turns
+// the expired ones into the expireInto element of their own, or its burnInto
+// for burning cells, keeping their temperature. It returns whether any cell
+// of the area has a lifetime, in which case the chunk must stay awake.
+func processChunkLifetime(w *World, startX int, startY int, endX int, endY int) bool {
+	living := false
+	for y := startY; y < endY; y++ {
+		for x := startX; x < endX; x++ {
+			i := y*w.width + x
+			cell := &w.cells[i]
+			if cell.Life == 0 {
+				continue
+			}
+			living = true
+			data := &CellsTypes[cell.Type]
+			burning := cell.Flags&Burning != 0
+			if burning && !burn(w, x, y) {
+				continue
+			}
+			cell.Life--
+			if cell.Life == 0 {
+				into := data.expireInto
+				if burning {
+					into = data.burnInto
+				}
+				expired := newCell(into, w.randAt(x, y))
+				expired.Temp = cell.Temp
+				w.Set(x, y, expired)
+				continue
+			}
+			if data.colorByAge && !burning {
+				if variant := cell.ageVariant(); variant != cell.Variant {
+					cell.Variant = variant
+					w.dirty[i] = true
+				}
+			}
+		}
+	}
+	return living
+}
+
+// ageVariant is the colour of a cell whose element is coloured by age: its
+// first colour when the cell has its longest life ahead, its last one when it
+// is about to expire.
+func (c Cell) ageVariant() uint8 {
+	data := &CellsTypes[c.Type]
+	colors := len(data.colors)
+	age := data.maxLife - int(c.Life)
+	return uint8(min(age*colors/data.maxLife, colors-1))
+}
@@ -0,0 +1,137 @@
+package sim
+
+const (
+	// plantMaxWater is the most water a plant cell holds in Cell.Data.
+	plantMaxWater = 250
+	// seedWater is the water of the first plant cell grown from a seed, and
+	// sipWater the water a plant cell gets from each water cell it drinks.
+	seedWater = 100
+	sipWater  = 60
+	// growWater is the water a plant cell needs to grow a new one, which
+	// gets half of it. growChance is the chance in percent that it grows at
+	// each tick, sproutChance the chance that a seed starts to grow.
+	growWater    = 60
+	growChance   = 5
+	sproutChance = 5
+	// thirstChance is the chance in percent that a plant cell uses one unit
+	// of water at each tick. It withers once it has none left.
+	thirstChance = 10
+)
+
+// SeedPhysic makes a seed fall like sand. Once it lies on its soil next to
+// water, it may turn into the element it grows into.
+func SeedPhysic(x int, y int, w *World) {
+	SandPhysic(x, y, w)
+	if w.hasMoved(x, y) {
+		return
+	}
+	data := &CellsTypes[w.Get(x, y).Type]
+	belowX, belowY := w.towards(x, y, 0)
+	if !w.InBounds(belowX, belowY) || w.Get(belowX, belowY).Type != data.soil {
+		return
+	}
+	if !touches(w, x, y, data.water) && !touches(w, belowX, belowY, data.water) {
+		return
+	}
+	// stay awake until the seed sprouts
+	w.wakeAround(x, y)
+	rng := w.randAt(x, y)
+	if rng.Intn(100) < sproutChance {
+		sprout := newCell(data.growsInto, rng)
+		sprout.Data = seedWater
+		w.Set(x, y, sprout)
+	}
+}
+
+// PlantPhysic runs a plant cell, whose Cell.Data is the water it holds. It
+// drinks the water around it, shares it with the plant cells near it and
+// uses it to grow, mostly away from gravity. It withers into its withersInto
+// element once it has no water left.
+func PlantPhysic(x int, y int, w *World) {
+	if w.hasMoved(x, y) {
+		return
+	}
+	w.wakeAround(x, y)
+	i := y*w.width + x
+	cell := &w.cells[i]
+	data := &CellsTypes[cell.Type]
+	rng := w.randAt(x, y)
+	if rng.Intn(100) < thirstChance {
+		if cell.Data == 0 {
+			withered := newCell(data.withersInto, rng)
+			withered.Temp = cell.Temp
+			w.Set(x, y, withered)
+			return
+		}
+		cell.Data--
+	}
+	// look at one cell at most two cells away, picked at random, so that
+	// roots reach the water soaking the soil
+	neighbourX := x + rng.Intn(5) - 2
+	neighbourY := y + rng.Intn(5) - 2
+	if !w.InBounds(neighbourX, neighbourY) || (neighbourX == x && neighbourY == y) {
+		return
+	}
+	neighbour := &w.cells[neighbourY*w.width+neighbourX]
+	switch {
+	case neighbour.Type == data.water && int(cell.Data)+sipWater <= plantMaxWater:
+		cell.Data += sipWater
+		w.Set(neighbourX, neighbourY, NewAirCell())
+	case neighbour.Type == cell.Type && cell.Data > neighbour.Data+1:
+		// water flows towards the drier cells, from the roots to the tips
+		shared := (cell.Data - neighbour.Data) / 2
+		cell.Data -= shared
+		neighbour.Data += shared
+	}
+	if cell.Data >= growWater && rng.Intn(100) < growChance {
+		grow(w, x, y)
+	}
+}
+
+// growthSteps are the directions a plant grows towards, as steps for
+// World.towards: away from gravity most of the time, sometimes sideways.
+var growthSteps = [10]int{4, 4, 4, 4, 4, 3, -3, 3, -3, 2}
+
+// grow makes the plant cell at (x, y) grow a new one into the air next to it,
+// giving it half of its water. It does not grow into crowded places, so that
+// plants branch out rather than fill the space.
+func grow(w *World, x int, y int) {
+	rng := w.randAt(x, y)
+	steps := growthSteps[rng.Intn(len(growthSteps))]
+	if steps == 2 && rng.Intn(2) == 0 {
+		steps = -2
+	}
+	targetX, targetY := w.towards(x, y, steps)
+	if !w.InBounds(targetX, targetY) || w.Get(targetX, targetY).Type != Air {
+		return
+	}
+	cell := &w.cells[y*w.width+x]
+	if countAround(w, targetX, targetY, cell.Type) > 2 {
+		return
+	}
+	cell.Data /= 2
+	shoot := newCell(cell.Type, rng)
+	shoot.Data = cell.Data
+	shoot.Temp = cell.Temp
+	w.Set(targetX, targetY, shoot)
+}
+
+// touches tells whether a neighbour of (x, y) is of the given element.
+func touches(w *World, x int, y int, cellType CellType) bool {
+	return countAround(w, x, y, cellType) > 0
+}
+
+// countAround counts the neighbours of (x, y) of the given element.
+func countAround(w *World, x int, y int, cellType CellType) int {
+	count := 0
+	for offsetY := -1; offsetY <= 1; offsetY++ {
+		for offsetX := -1; offsetX <= 1; offsetX++ {
+			targetX := x + offsetX
+			targetY := y + offsetY
+			if (offsetX != 0 || offsetY != 0) && w.InBounds(targetX, targetY) && w.Get(targetX, targetY).Type == cellType {
+				count++
+			}
+		}
+	}
+	return count
+}
@@ -0,0 +1,30 @@
+package sim
+
+import "testing"
+
+func TestPlantWaterUseDoesNotDependOnRow(t *testing.T) {
+	w := NewWorld(32, 32, 1)
+	plant := element(t, "Plant")
+	glass := element(t, "Glass")
+	// two plants full of water on an even and an odd row, walled in so
+	// that they cannot grow
+	for _, y := range []int{10, 21} {
+		fill(w, 9, y-1, 11, y+1, glass)
+		cell := w.NewCell(plant)
+		cell.Data = plantMaxWater
+		w.Set(10, y, cell)
+	}
+	for range 1000 {
+		w.Tick()
+	}
+	// a plant uses one unit of water every ten ticks on average
+	for _, y := range []int{10, 21} {
+		cell := w.Get(10, y)
+		if cell.Type != plant {
+			t.Fatalf("the plant on row %d withered", y)
+		}
+		if used := plantMaxWater - int(cell.Data); used < 60 || used > 140 {
+			t.Errorf("the plant on row %d used %d water in 1000 ticks, expected about 100", y, used)
+		}
+	}
+}
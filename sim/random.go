@@ -0,0 +1,34 @@
+package sim
+
+import "math/bits"
+
+// Rand is a small splitmix64 generator. Unlike math/rand it can be reseeded
+// for free, which lets every chunk draw from its own sequence at each tick
+// whatever the goroutine that processes it.
+type Rand struct {
+	state uint64
+}
+
+func NewRand(seed int64) *Rand {
+	r := &Rand{}
+	r.Seed(uint64(seed))
+	return r
+}
+
+func (r *Rand) Seed(seed uint64) {
+	r.state = seed
+}
+
+func (r *Rand) Uint64() uint64 {
+	r.state += 0x9e3779b97f4a7c15
+	z := r.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Intn returns a number in [0, n). n must be positive.
+func (r *Rand) Intn(n int) int {
+	hi, _ := bits.Mul64(r.Uint64(), uint64(n))
+	return int(hi)
+}
@@ -0,0 +1,116 @@
+package sim
+
+const (
+	// Any matches every element in a reaction, except the reacting one.
+	Any CellType = 255
+	// Keep leaves a cell unchanged by a reaction.
+	Keep CellType = 254
+)
+
+// Reaction turns a cell and one of its eight neighbours into other elements
+// when they touch. For instance water meeting lava is written
+//
+//	{"with": "Lava", "into": "Stone", "with_into": "Steam"}
+//
+// in the reactions of Water in the elements file.
+type Reaction struct {
+	// With is the element of the neighbour, or Any.
+	With CellType
+	// Probability is the chance in percent that the reaction happens at
+	// each tick for each matching neighbour.
+	Probability int
+	// Into is the element the cell turns into, or Keep.
+	Into CellType
+	// WithInto is the element the neighbour turns into, or Keep.
+	WithInto CellType
+	// Explosion is the power of an explosion set off at the cell when the
+	// reaction happens, none when zero.
+	Explosion int
+}
+
+// processChunkReactions applies the reactions of the elements of the area,
+// sets the neighbours of the elements that ignite on fire and makes the
+// flickering ones change colour. It returns whether any cell ignites or
+// flickers, in which case the chunk must stay awake.
+func processChunkReactions(w *World, startX int, startY int, endX int, endY int) bool {
+	active := false
+	for y := startY; y < endY; y++ {
+		for x := startX; x < endX; x++ {
+			data := &CellsTypes[w.Get(x, y).Type]
+			if len(data.reactions) > 0 {
+				react(w, x, y)
+			}
+			if data.ignites {
+				igniteAround(w, x, y)
+				active = true
+			}
+			if data.flicker > 0 {
+				flicker(w, x, y)
+				active = true
+			}
+		}
+	}
+	return active
+}
+
+// flicker gives the cell at (x, y) another of its colours from time to time.
+func flicker(w *World, x int, y int) {
+	i := y*w.width + x
+	cell := &w.cells[i]
+	rng := w.randAt(x, y)
+	if rng.Intn(100) < CellsTypes[cell.Type].flicker {
+		cell.Variant = randomVariant(cell.Type, rng)
+		w.dirty[i] = true
+	}
+}
+
+// react checks the reactions of the cell at (x, y) against each of its
+// neighbours. It stops as soon as the cell itself turns into something else.
+func react(w *World, x int, y int) {
+	rng := w.randAt(x, y)
+	for offsetY := -1; offsetY <= 1; offsetY++ {
+		for offsetX := -1; offsetX <= 1; offsetX++ {
+			targetX := x + offsetX
+			targetY := y + offsetY
+			if (offsetX == 0 && offsetY == 0) || !w.InBounds(targetX, targetY) {
+				continue
+			}
+			cell := w.Get(x, y)
+			target := w.Get(targetX, targetY)
+			for _, reaction := range CellsTypes[cell.Type].reactions {
+				if !reaction.changes(cell.Type, target.Type) {
+					continue
+				}
+				if reaction.Probability < 100 && rng.Intn(100) >= reaction.Probability {
+					continue
+				}
+				if reaction.Explosion > 0 {
+					w.Explode(x, y, reaction.Explosion)
+				}
+				if reaction.WithInto != Keep {
+					w.Set(targetX, targetY, newCell(reaction.WithInto, rng))
+				}
+				if reaction.Into != Keep {
+					w.Set(x, y, newCell(reaction.Into, rng))
+					return
+				}
+				break
+			}
+		}
+	}
+}
+
+// changes tells whether the reaction applies between a cell of cellType and a
+// neighbour of targetType and would change one of them or explode.
+func (r Reaction) changes(cellType CellType, targetType CellType) bool {
+	if r.With == Any {
+		if targetType == cellType {
+			return false
+		}
+	} else if r.With != targetType {
+		return false
+	}
+	changesCell := r.Into != Keep && r.Into != cellType
+	changesTarget := r.WithInto != Keep && r.WithInto != targetType
+	return changesCell || changesTarget || r.Explosion > 0
+}
@@ -0,0 +1,170 @@
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Scene is the on-disk representation of a world.
+type Scene struct {
+	Width  int   `json:"width"`
+	Height int   `json:"height"`
+	Seed   int64 `json:"seed"`
+	// Gravity and GravityStrength are the arguments of World.SetGravity.
+	Gravity         Direction `json:"gravity"`
+	GravityStrength int       `json:"gravity_strength"`
+	// Elements holds the names of the elements the cells refer to, so that a
+	// scene still loads when the elements file changes. Scenes without it use
+	// the current elements.
+	Elements []string   `json:"elements,omitempty"`
+	Cells    []CellType `json:"cells"`
+	// Life holds the remaining life of each cell, see Cell.Life. It is left
+	// out when no cell expires.
+	Life []uint16 `json:"life,omitempty"`
+	// Flags holds the flags of each cell, see Cell.Flags, such as the
+	// direction of the gates and the state of the switches. It is left out
+	// when no cell has any.
+	Flags []uint8 `json:"flags,omitempty"`
+	// Data holds the per-cell value of each cell, see Cell.Data. It is left
+	// out when no cell has any.
+	Data []uint8 `json:"data,omitempty"`
+}
+
+func (w *World) Scene() Scene {
+	scene := Scene{
+		Width:           w.width,
+		Height:          w.height,
+		Seed:            w.seed,
+		Gravity:         w.gravity,
+		GravityStrength: w.gravityStrength,
+		Elements:        make([]string, len(CellsTypes)),
+		Cells:           make([]CellType, len(w.cells)),
+	}
+	for i := range CellsTypes {
+		scene.Elements[i] = CellsTypes[i].name
+	}
+	for i, cell := range w.cells {
+		scene.Cells[i] = cell.Type
+		if cell.Life != 0 {
+			if scene.Life == nil {
+				scene.Life = make([]uint16, len(w.cells))
+			}
+			scene.Life[i] = cell.Life
+		}
+		if cell.Flags != 0 {
+			if scene.Flags == nil {
+				scene.Flags = make([]uint8, len(w.cells))
+			}
+			scene.Flags[i] = cell.Flags
+		}
+		if cell.Data != 0 {
+			if scene.Data == nil {
+				scene.Data = make([]uint8, len(w.cells))
+			}
+			scene.Data[i] = cell.Data
+		}
+	}
+	return scene
+}
+
+// NewWorldFromScene rebuilds a world from a scene, giving every cell a fresh
+// colour from its element.
+func NewWorldFromScene(scene Scene) (*World, error) {
+	if scene.Width <= 0 || scene.Height <= 0 {
+		return nil, fmt.Errorf("invalid scene size %dx%d", scene.Width, scene.Height)
+	}
+	if len(scene.Cells) != scene.Width*scene.Height {
+		return nil, fmt.Errorf("scene has %d cells, expected %d", len(scene.Cells), scene.Width*scene.Height)
+	}
+	if scene.Gravity > NoDirection {
+		return nil, fmt.Errorf("invalid gravity direction %d", scene.Gravity)
+	}
+	if scene.Life != nil && len(scene.Life) != len(scene.Cells) {
+		return nil, fmt.Errorf("scene has %d lifetimes for %d cells", len(scene.Life), len(scene.Cells))
+	}
+	if scene.Flags != nil && len(scene.Flags) != len(scene.Cells) {
+		return nil, fmt.Errorf("scene has %d flags for %d cells", len(scene.Flags), len(scene.Cells))
+	}
+	if scene.Data != nil && len(scene.Data) != len(scene.Cells) {
+		return nil, fmt.Errorf("scene has %d data values for %d cells", len(scene.Data), len(scene.Cells))
+	}
+	cellTypes, err := scene.cellTypes()
+	if err != nil {
+		return nil, err
+	}
+	w := NewWorld(scene.Width, scene.Height, scene.Seed)
+	w.SetGravity(scene.Gravity, scene.GravityStrength)
+	for i, cellType := range scene.Cells {
+		if int(cellType) >= len(cellTypes) {
+			return nil, fmt.Errorf("unknown cell type %d at index %d", cellType, i)
+		}
+		w.cells[i] = w.NewCell(cellTypes[cellType])
+		if scene.Life != nil && w.cells[i].Life != 0 && scene.Life[i] != 0 {
+			w.cells[i].Life = min(scene.Life[i], uint16(CellsTypes[w.cells[i].Type].maxLife))
+			if CellsTypes[w.cells[i].Type].colorByAge {
+				w.cells[i].Variant = w.cells[i].ageVariant()
+			}
+		}
+		if scene.Flags != nil {
+			// burning cells are not saved with their burn time, they are
+			// put out
+			w.cells[i].Flags = scene.Flags[i] &^ Burning
+			if CellsTypes[w.cells[i].Type].toggles {
+				w.cells[i].setSwitch(scene.Flags[i]&SwitchedOn != 0)
+			}
+		}
+		if scene.Data != nil {
+			w.cells[i].Data = scene.Data[i]
+		}
+	}
+	return w, nil
+}
+
+// cellTypes maps the cell types of the scene to the current elements.
+func (scene Scene) cellTypes() ([]CellType, error) {
+	if len(scene.Elements) == 0 {
+		cellTypes := make([]CellType, len(CellsTypes))
+		for i := range cellTypes {
+			cellTypes[i] = CellType(i)
+		}
+		return cellTypes, nil
+	}
+	cellTypes := make([]CellType, len(scene.Elements))
+	for i, name := range scene.Elements {
+		cellType, ok := ElementByName(name)
+		if !ok {
+			return nil, fmt.Errorf("scene uses unknown element %q", name)
+		}
+		cellTypes[i] = cellType
+	}
+	return cellTypes, nil
+}
+
+func ReadScene(path string) (Scene, error) {
+	scene := Scene{GravityStrength: 100}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return scene, err
+	}
+	if err := json.Unmarshal(data, &scene); err != nil {
+		return scene, fmt.Errorf("%s: %w", path, err)
+	}
+	return scene, nil
+}
+
+func LoadScene(path string) (*World, error) {
+	scene, err := ReadScene(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewWorldFromScene(scene)
+}
+
+func (w *World) SaveScene(path string) error {
+	data, err := json.Marshal(w.Scene())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,137 @@
+package sim
+
+const (
+	// velocityScale is the number of velocity units in one cell per tick.
+	velocityScale = 16
+	// gravityAcceleration is added to the velocity of falling cells at every
+	// tick under normal gravity.
+	gravityAcceleration = 4
+	// terminalVelocity caps the speed of a cell. It must stay below
+	// ChunkSize/2 cells per tick, see ChunkSize.
+	terminalVelocity = 6 * velocityScale
+	// friction is removed from the sideways velocity at every tick.
+	friction = 4
+	// landingScatter is the percentage of its falling speed a cell keeps
+	// sideways when it lands.
+	landingScatter = 50
+)
+
+// cellsToTravel converts a velocity into a number of cells for this tick. The
+// fractional part is rounded up at random so that slow cells still move on
+// average at their velocity.
+func cellsToTravel(velocity int, rng *Rand) int {
+	cells := velocity / velocityScale
+	if remainder := velocity % velocityScale; remainder > 0 && rng.Intn(velocityScale) < remainder {
+		cells++
+	}
+	return cells
+}
+
+// fall accelerates the cell at (x, y) along gravity and moves it cell by cell
+// until it has travelled its velocity or meets an obstacle. A cell thrown
+// against gravity rises until gravity slows it down. When it lands, part of
+// its speed is turned into sideways velocity. It returns whether the cell is
+// falling or rising, even if too slowly to have moved during this tick.
+func fall(w *World, x int, y int) bool {
+	if w.hasMoved(x, y) {
+		return false
+	}
+	cell := &w.cells[y*w.width+x]
+	pulled := w.feelsGravity(cell.Type)
+	if !pulled && cell.VelY == 0 {
+		return false
+	}
+	rng := w.randAt(x, y)
+	dx, dy := w.down().Vector()
+	velocity := min(int(cell.VelY)+w.acceleration(cell.Type, rng), terminalVelocity)
+	if !pulled {
+		// without gravity, thrown cells slow down like sliding ones
+		velocity = int(cell.VelY) - max(min(int(cell.VelY), friction), -friction)
+	}
+	if velocity < 0 {
+		dx, dy = -dx, -dy
+	}
+	steps := cellsToTravel(abs(velocity), rng)
+	probe := max(steps, 1)
+	free := 0
+	for free < probe {
+		targetX := x + dx*(free+1)
+		targetY := y + dy*(free+1)
+		if !w.InBounds(targetX, targetY) || w.hasMoved(targetX, targetY) || !cell.canSwitchWith(w.Get(targetX, targetY)) {
+			break
+		}
+		free++
+	}
+	if free < probe && velocity < 0 && w.followedBy(x+dx*(free+1), y+dy*(free+1), 0, velocity) {
+		// keep rising behind the cells thrown in front of this one
+	} else if free < probe {
+		if velocity >= 2*velocityScale {
+			scatter := velocity * landingScatter / 100
+			if rng.Intn(2) == 0 {
+				scatter = -scatter
+			}
+			cell.VelX = clampVelocity(int(cell.VelX) + scatter)
+		}
+		velocity = 0
+	}
+	cell.VelY = int8(velocity)
+	travelled := min(free, steps)
+	for i := 0; i < travelled; i++ {
+		switchPlace(x+dx*i, y+dy*i, x+dx*(i+1), y+dy*(i+1), w)
+	}
+	if free > 0 && travelled == 0 {
+		w.wakeAround(x, y)
+	}
+	return free > 0
+}
+
+// slide moves the cell at (x, y) across gravity along its sideways velocity,
+// which friction slowly brings back to zero. It returns whether the cell
+// moved.
+func slide(w *World, x int, y int) bool {
+	cell := &w.cells[y*w.width+x]
+	if cell.VelX == 0 || w.hasMoved(x, y) {
+		return false
+	}
+	velocity := int(cell.VelX)
+	turn := 2
+	if velocity < 0 {
+		turn = -2
+		velocity = -velocity
+	}
+	dx, dy := w.down().Rotate(turn).Vector()
+	probe := max(cellsToTravel(velocity, w.randAt(x, y)), 1)
+	travelled := 0
+	for travelled < probe {
+		targetX := x + dx*(travelled+1)
+		targetY := y + dy*(travelled+1)
+		if !w.InBounds(targetX, targetY) || w.hasMoved(targetX, targetY) || !cell.canSwitchWith(w.Get(targetX, targetY)) {
+			break
+		}
+		travelled++
+	}
+	if travelled < probe && !w.followedBy(x+dx*(travelled+1), y+dy*(travelled+1), int(cell.VelX), 0) {
+		cell.VelX = 0
+	} else {
+		cell.VelX = int8(turn / 2 * max(velocity-friction, 0))
+	}
+	for i := 0; i < travelled; i++ {
+		switchPlace(x+dx*i, y+dy*i, x+dx*(i+1), y+dy*(i+1), w)
+	}
+	return travelled > 0
+}
+
+// followedBy tells whether the cell at (x, y) is moving the same way as a
+// cell with the given sideways or gravity velocity blocked by it, in which
+// case the blocked cell keeps its velocity to follow it at the next tick.
+func (w *World) followedBy(x int, y int, velocityX int, velocityY int) bool {
+	if !w.InBounds(x, y) {
+		return false
+	}
+	blocker := w.Get(x, y)
+	return int(blocker.VelX)*velocityX > 0 || int(blocker.VelY)*velocityY > 0
+}
+
+func clampVelocity(velocity int) int8 {
+	return int8(max(min(velocity, terminalVelocity), -terminalVelocity))
+}
@@ -0,0 +1,244 @@
+package sim
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ChunkSize is the side in cells of the square chunks the world is split into.
+// A chunk in which nothing changed during a tick goes to sleep and its cells
+// are skipped until something wakes it.
+//
+// Chunks are processed in four phases so that no two chunks processed at the
+// same time are adjacent. The physics of a cell must therefore never reach
+// further than ChunkSize/2 cells away from it.
+const ChunkSize = 16
+
+// World owns the grid of cells and runs the physics. It has no dependency on
+// Ebiten so it can be simulated without opening a window.
+type World struct {
+	width  int
+	height int
+	cells  []Cell
+	seed   int64
+	// rng is used outside of the physics, for cells created by the brush or
+	// loaded from a scene. Each chunk has its own generator during a tick.
+	rng        *Rand
+	chunkRands []Rand
+	// generation is incremented at every tick. A cell whose moved entry
+	// equals it has already moved during the current tick.
+	generation uint32
+	moved      []uint32
+	// dirty marks the cells that changed since the renderer last cleared it.
+	dirty []bool
+	// awake holds the chunks processed by the current tick, wakeNext the
+	// ones that will be processed by the next one.
+	chunksWidth  int
+	chunksHeight int
+	awake        []atomic.Bool
+	wakeNext     []atomic.Bool
+	// gravity is the direction cells fall towards, gravityStrength its
+	// strength in percent.
+	gravity         Direction
+	gravityStrength int
+	workers         int
+	started         int
+	jobs            chan struct{}
+	phaseChunks     []int
+	nextChunk       atomic.Int64
+	phaseWait       sync.WaitGroup
+	// explosions are queued during a tick and applied at its end, see
+	// explosion.
+	explosionsLock sync.Mutex
+	explosions     []explosion
+	applying       []explosion
+}
+
+// NewWorld creates a world of width×height cells filled with air. Every random
+// choice made by the world comes from seed, so two worlds with the same seed
+// given the same inputs stay identical.
+func NewWorld(width int, height int, seed int64) *World {
+	chunksWidth := (width + ChunkSize - 1) / ChunkSize
+	chunksHeight := (height + ChunkSize - 1) / ChunkSize
+	w := &World{
+		width:           width,
+		height:          height,
+		cells:           make([]Cell, width*height),
+		seed:            seed,
+		rng:             NewRand(seed),
+		chunkRands:      make([]Rand, chunksWidth*chunksHeight),
+		moved:           make([]uint32, width*height),
+		dirty:           make([]bool, width*height),
+		chunksWidth:     chunksWidth,
+		chunksHeight:    chunksHeight,
+		awake:           make([]atomic.Bool, chunksWidth*chunksHeight),
+		wakeNext:        make([]atomic.Bool, chunksWidth*chunksHeight),
+		gravity:         Down,
+		gravityStrength: 100,
+		workers:         1,
+		phaseChunks:     make([]int, 0, chunksWidth*chunksHeight),
+	}
+	for i := range w.cells {
+		w.cells[i] = NewAirCell()
+		w.dirty[i] = true
+	}
+	for i := range w.wakeNext {
+		w.wakeNext[i].Store(true)
+	}
+	return w
+}
+
+func (w *World) Width() int {
+	return w.width
+}
+
+func (w *World) Height() int {
+	return w.height
+}
+
+func (w *World) Seed() int64 {
+	return w.seed
+}
+
+// SetWorkers sets the number of goroutines running the physics. The result of
+// a tick does not depend on it. Call Close to stop the extra goroutines once
+// the world is no longer used.
+func (w *World) SetWorkers(workers int) {
+	w.workers = max(workers, 1)
+	if w.jobs == nil {
+		w.jobs = make(chan struct{})
+	}
+	for ; w.started < w.workers-1; w.started++ {
+		go w.phaseWorker(w.jobs)
+	}
+}
+
+// Close stops the goroutines started by SetWorkers.
+func (w *World) Close() {
+	if w.jobs != nil {
+		close(w.jobs)
+		w.jobs = nil
+		w.started = 0
+		w.workers = 1
+	}
+}
+
+func (w *World) InBounds(x int, y int) bool {
+	return x >= 0 && x < w.width && y >= 0 && y < w.height
+}
+
+func (w *World) Get(x int, y int) Cell {
+	return w.cells[y*w.width+x]
+}
+
+// Set replaces a cell. The new cell cannot move again until the next tick.
+func (w *World) Set(x int, y int, cell Cell) {
+	i := y*w.width + x
+	w.cells[i] = cell
+	w.moved[i] = w.generation
+	w.dirty[i] = true
+	w.wakeAround(x, y)
+}
+
+// wakeAround wakes the chunk holding (x, y) and the neighbouring chunks the
+// cell borders, since their cells may now be able to move into it.
+func (w *World) wakeAround(x int, y int) {
+	minX := max(x-1, 0) / ChunkSize
+	maxX := min(x+1, w.width-1) / ChunkSize
+	minY := max(y-1, 0) / ChunkSize
+	maxY := min(y+1, w.height-1) / ChunkSize
+	for cy := minY; cy <= maxY; cy++ {
+		for cx := minX; cx <= maxX; cx++ {
+			if chunk := &w.wakeNext[cy*w.chunksWidth+cx]; !chunk.Load() {
+				chunk.Store(true)
+			}
+		}
+	}
+}
+
+func (w *World) ChunksWidth() int {
+	return w.chunksWidth
+}
+
+func (w *World) ChunksHeight() int {
+	return w.chunksHeight
+}
+
+// ChunkAwake tells whether the chunk at (cx, cy), in chunk coordinates, was
+// processed by the last tick.
+func (w *World) ChunkAwake(cx int, cy int) bool {
+	return w.awake[cy*w.chunksWidth+cx].Load()
+}
+
+// randAt returns the random generator of the chunk holding (x, y).
+func (w *World) randAt(x int, y int) *Rand {
+	return &w.chunkRands[(y/ChunkSize)*w.chunksWidth+x/ChunkSize]
+}
+
+func (w *World) hasMoved(x int, y int) bool {
+	return w.moved[y*w.width+x] == w.generation
+}
+
+// Dirty tells whether the cell changed since the last call to ClearDirty.
+func (w *World) Dirty(x int, y int) bool {
+	return w.dirty[y*w.width+x]
+}
+
+func (w *World) ClearDirty() {
+	clear(w.dirty)
+}
+
+// Tick advances the simulation by one step.
+func (w *World) Tick() {
+	w.generation++
+	w.awake, w.wakeNext = w.wakeNext, w.awake
+	for i := range w.wakeNext {
+		w.wakeNext[i].Store(false)
+	}
+	for phase := 0; phase < 4; phase++ {
+		w.phaseChunks = w.phaseChunks[:0]
+		for cy := phase / 2; cy < w.chunksHeight; cy += 2 {
+			for cx := phase % 2; cx < w.chunksWidth; cx += 2 {
+				chunk := cy*w.chunksWidth + cx
+				if w.awake[chunk].Load() {
+					w.phaseChunks = append(w.phaseChunks, chunk)
+				}
+			}
+		}
+		w.runPhase()
+	}
+	w.applyExplosions()
+}
+
+// runPhase processes the awake chunks of a phase, spreading them over the
+// workers.
+func (w *World) runPhase() {
+	if len(w.phaseChunks) == 0 {
+		return
+	}
+	workers := min(w.workers, len(w.phaseChunks))
+	w.nextChunk.Store(0)
+	w.phaseWait.Add(workers - 1)
+	for i := 1; i < workers; i++ {
+		w.jobs <- struct{}{}
+	}
+	w.processPhaseChunks()
+	w.phaseWait.Wait()
+}
+
+func (w *World) phaseWorker(jobs chan struct{}) {
+	for range jobs {
+		w.processPhaseChunks()
+		w.phaseWait.Done()
+	}
+}
+
+func (w *World) processPhaseChunks() {
+	for {
+		i := int(w.nextChunk.Add(1)) - 1
+		if i >= len(w.phaseChunks) {
+			return
+		}
+		processChunkPhysic(w, w.phaseChunks[i])
+	}
+}
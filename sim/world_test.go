@@ -0,0 +1,110 @@
+package sim
+
+import (
+	"reflect"
+	"testing"
+)
+
+// element returns the CellType of a built-in element, failing the test when
+// it does not exist.
+func element(t testing.TB, name string) CellType {
+	t.Helper()
+	cellType, ok := ElementByName(name)
+	if !ok {
+		t.Fatalf("no element %q", name)
+	}
+	return cellType
+}
+
+// fill sets every cell of the rectangle from (x0, y0) to (x1, y1) included.
+func fill(w *World, x0 int, y0 int, x1 int, y1 int, cellType CellType) {
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			w.Set(x, y, w.NewCell(cellType))
+		}
+	}
+}
+
+// newMixedWorld builds a world of width×height cells with piles of sand,
+// a pool of water, a metal shelf and a water generator, so that most of the
+// physics runs when it ticks.
+func newMixedWorld(t testing.TB, width int, height int, seed int64) *World {
+	t.Helper()
+	w := NewWorld(width, height, seed)
+	fill(w, 0, 0, width-1, height/10, element(t, "Sand"))
+	fill(w, width*2/5, height*4/5, width*3/5, height-1, element(t, "Water"))
+	fill(w, width/5, height/2, width*2/5, height/2, element(t, "Metal"))
+	fill(w, width*3/4, height*3/10, width*3/4+2, height*3/10, element(t, "Water Generator"))
+	return w
+}
+
+// assertSameScene fails the test when two worlds do not hold the same cells.
+func assertSameScene(t *testing.T, want *World, got *World) {
+	t.Helper()
+	if !reflect.DeepEqual(want.Scene(), got.Scene()) {
+		t.Fatal("the worlds differ")
+	}
+	for i := range want.cells {
+		if want.cells[i] != got.cells[i] {
+			t.Fatalf("cell %d differs: %+v, expected %+v", i, got.cells[i], want.cells[i])
+		}
+	}
+}
+
+func TestSameSeedGivesSameWorld(t *testing.T) {
+	a := newMixedWorld(t, 64, 48, 42)
+	b := newMixedWorld(t, 64, 48, 42)
+	for range 200 {
+		a.Tick()
+		b.Tick()
+	}
+	assertSameScene(t, a, b)
+}
+
+func TestDifferentSeedsGiveDifferentWorlds(t *testing.T) {
+	a := newMixedWorld(t, 64, 48, 1)
+	b := newMixedWorld(t, 64, 48, 2)
+	for range 200 {
+		a.Tick()
+		b.Tick()
+	}
+	if reflect.DeepEqual(a.Scene().Cells, b.Scene().Cells) {
+		t.Fatal("two seeds gave the same cells")
+	}
+}
+
+func TestWorkersGiveSameWorld(t *testing.T) {
+	single, err := NewBenchmarkWorld(200, 200, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parallel, err := NewBenchmarkWorld(200, 200, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parallel.SetWorkers(8)
+	defer parallel.Close()
+	for range 200 {
+		single.Tick()
+		parallel.Tick()
+	}
+	assertSameScene(t, single, parallel)
+}
+
+func TestEachCellRunsOncePerTick(t *testing.T) {
+	metal := element(t, "Metal")
+	physic := CellsTypes[metal].physic
+	defer func() { CellsTypes[metal].physic = physic }()
+	w := NewWorld(40, 40, 1)
+	runs := make([]int, len(w.cells))
+	CellsTypes[metal].physic = func(x int, y int, w *World) {
+		runs[y*w.width+x]++
+	}
+	fill(w, 0, 0, 39, 39, metal)
+	w.Tick()
+	for i, count := range runs {
+		if count != 1 {
+			t.Fatalf("the cell at (%d, %d) ran %d times", i%w.width, i/w.width, count)
+		}
+	}
+}
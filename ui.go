@@ -7,6 +7,7 @@ import (
 	"github.com/ebitenui/ebitenui/widget"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"go_project/sim"
 	"golang.org/x/image/font/gofont/goregular"
 	"image/color"
 	"log"
@@ -28,17 +29,18 @@ func loadFont(size float64) (text.Face, error) {
 
 func setupUI(g *Game) {
 	res := newResources()
-	var buttons = make([]*widget.Button, 0)
-	var elements = []buttonData{
-		{"Sand", Sand},
-		{"Water", Water},
-		{"Air", Air},
-		{"Metal", Metal},
-		{"Black Hole", BlackHole},
-		{"Water Generator", WaterGenerator}}
-
-	for _, el := range elements {
-		buttons = append(buttons, createButton(g, res, el.label, el.cellType))
+	// one button per element, on two columns so that long element lists fit
+	elementButtons := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewGridLayout(
+			widget.GridLayoutOpts.Columns(2),
+			widget.GridLayoutOpts.Stretch([]bool{true, true}, nil),
+			widget.GridLayoutOpts.Spacing(4, 4),
+		)),
+		widget.ContainerOpts.WidgetOpts(widget.WidgetOpts.LayoutData(widget.RowLayoutData{Stretch: true})),
+	)
+	for i := range sim.CellsTypes {
+		cellType := sim.CellType(i)
+		elementButtons.AddChild(createButton(g, res, cellType.String(), cellType))
 	}
 
 	slider := widget.NewSlider(
@@ -53,62 +55,89 @@ func setupUI(g *Game) {
 		widget.SliderOpts.Direction(widget.DirectionHorizontal),
 	)
 
-	checkboxShowOnlyUpdated := widget.NewCheckbox(
-		widget.CheckboxOpts.ButtonOpts(
-			widget.ButtonOpts.WidgetOpts(
-				// Set the location of the checkboxShowOnlyUpdated
-				widget.WidgetOpts.LayoutData(widget.AnchorLayoutData{
-					HorizontalPosition: widget.AnchorLayoutPositionCenter,
-					VerticalPosition:   widget.AnchorLayoutPositionCenter,
-				}),
-				// Set the minimum size of the checkboxShowOnlyUpdated
-				widget.WidgetOpts.MinSize(30, 30),
-			),
-			// Set the background images - idle, hover, pressed
-			widget.ButtonOpts.Image(res.buttonImage),
-		),
-		widget.CheckboxOpts.Image(res.checkboxImage),
-		widget.CheckboxOpts.StateChangedHandler(func(args *widget.CheckboxChangedEventArgs) {
-			onlyShowUpdatedCells = args.State == widget.WidgetChecked
+	checkboxShowOnlyUpdated := createCheckbox(res, func(checked bool) {
+		onlyShowUpdatedCells = checked
+	})
+	checkboxUpdateAllCells := createCheckbox(res, func(checked bool) {
+		updateAllCells = checked
+	})
+	checkboxOnlyOneColor := createCheckbox(res, func(checked bool) {
+		sim.OnlyOneColor = checked
+	})
+	checkboxShowAwakeChunks := createCheckbox(res, func(checked bool) {
+		showAwakeChunks = checked
+	})
+	checkboxShowTemperature := createCheckbox(res, func(checked bool) {
+		showTemperature = checked
+		redrawAll = true
+	})
+
+	direction, strength := g.world.Gravity()
+	gravityButton := widget.NewButton(
+		widget.ButtonOpts.WidgetOpts(widget.WidgetOpts.LayoutData(widget.RowLayoutData{Stretch: true})),
+		widget.ButtonOpts.Image(res.buttonImage),
+		widget.ButtonOpts.Text("Gravity: "+direction.String(), res.font, res.textColor),
+		widget.ButtonOpts.TextPadding(res.padding),
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			direction, strength := g.world.Gravity()
+			direction = (direction + 1) % (sim.NoDirection + 1)
+			g.world.SetGravity(direction, strength)
+			args.Button.Text().Label = "Gravity: " + direction.String()
 		}),
 	)
 
-	checkboxUpdateAllCells := widget.NewCheckbox(
-		widget.CheckboxOpts.ButtonOpts(
-			widget.ButtonOpts.WidgetOpts(
-				// Set the location of the checkboxShowOnlyUpdated
-				widget.WidgetOpts.LayoutData(widget.AnchorLayoutData{
-					HorizontalPosition: widget.AnchorLayoutPositionCenter,
-					VerticalPosition:   widget.AnchorLayoutPositionCenter,
-				}),
-				// Set the minimum size of the checkboxShowOnlyUpdated
-				widget.WidgetOpts.MinSize(30, 30),
-			),
-			// Set the background images - idle, hover, pressed
-			widget.ButtonOpts.Image(res.buttonImage),
-		),
-		widget.CheckboxOpts.Image(res.checkboxImage),
-		widget.CheckboxOpts.StateChangedHandler(func(args *widget.CheckboxChangedEventArgs) {
-			updateAllCells = args.State == widget.WidgetChecked
+	gravitySlider := widget.NewSlider(
+		widget.SliderOpts.MinMax(0, 300),
+		widget.SliderOpts.InitialCurrent(strength),
+		widget.SliderOpts.WidgetOpts(widget.WidgetOpts.LayoutData(widget.RowLayoutData{Stretch: true})),
+		widget.SliderOpts.Images(res.sliderImage, res.buttonImage),
+		widget.SliderOpts.ChangedHandler(func(args *widget.SliderChangedEventArgs) {
+			direction, _ := g.world.Gravity()
+			g.world.SetGravity(direction, args.Current)
 		}),
+		widget.SliderOpts.Direction(widget.DirectionHorizontal),
 	)
-	checkboxOnlyOneColor := widget.NewCheckbox(
-		widget.CheckboxOpts.ButtonOpts(
-			widget.ButtonOpts.WidgetOpts(
-				// Set the location of the checkboxShowOnlyUpdated
-				widget.WidgetOpts.LayoutData(widget.AnchorLayoutData{
-					HorizontalPosition: widget.AnchorLayoutPositionCenter,
-					VerticalPosition:   widget.AnchorLayoutPositionCenter,
-				}),
-				// Set the minimum size of the checkboxShowOnlyUpdated
-				widget.WidgetOpts.MinSize(30, 30),
-			),
-			// Set the background images - idle, hover, pressed
-			widget.ButtonOpts.Image(res.buttonImage),
-		),
-		widget.CheckboxOpts.Image(res.checkboxImage),
-		widget.CheckboxOpts.StateChangedHandler(func(args *widget.CheckboxChangedEventArgs) {
-			onlyOneColor = args.State == widget.WidgetChecked
+
+	detonatorButton := widget.NewButton(
+		widget.ButtonOpts.WidgetOpts(widget.WidgetOpts.LayoutData(widget.RowLayoutData{Stretch: true})),
+		widget.ButtonOpts.Image(res.buttonImage),
+		widget.ButtonOpts.Text("Detonator", res.font, res.textColor),
+		widget.ButtonOpts.TextPadding(res.padding),
+		widget.ButtonOpts.ClickedHandler(func(*widget.ButtonClickedEventArgs) {
+			g.detonator = true
+		}),
+	)
+
+	explosionPowerSlider := widget.NewSlider(
+		widget.SliderOpts.MinMax(1, sim.MaxExplosionPower),
+		widget.SliderOpts.InitialCurrent(g.explosionPower),
+		widget.SliderOpts.WidgetOpts(widget.WidgetOpts.LayoutData(widget.RowLayoutData{Stretch: true})),
+		widget.SliderOpts.Images(res.sliderImage, res.buttonImage),
+		widget.SliderOpts.ChangedHandler(func(args *widget.SliderChangedEventArgs) {
+			g.explosionPower = args.Current
+		}),
+		widget.SliderOpts.Direction(widget.DirectionHorizontal),
+	)
+
+	facingButton := widget.NewButton(
+		widget.ButtonOpts.WidgetOpts(widget.WidgetOpts.LayoutData(widget.RowLayoutData{Stretch: true})),
+		widget.ButtonOpts.Image(res.buttonImage),
+		widget.ButtonOpts.Text("Gates facing: "+g.facing.String(), res.font, res.textColor),
+		widget.ButtonOpts.TextPadding(res.padding),
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			// a quarter turn clockwise
+			g.facing = g.facing.Rotate(2)
+			args.Button.Text().Label = "Gates facing: " + g.facing.String()
+		}),
+	)
+
+	saveButton := widget.NewButton(
+		widget.ButtonOpts.WidgetOpts(widget.WidgetOpts.LayoutData(widget.RowLayoutData{Stretch: true})),
+		widget.ButtonOpts.Image(res.buttonImage),
+		widget.ButtonOpts.Text("Save Scene", res.font, res.textColor),
+		widget.ButtonOpts.TextPadding(res.padding),
+		widget.ButtonOpts.ClickedHandler(func(*widget.ButtonClickedEventArgs) {
+			saveScene(g)
 		}),
 	)
 
@@ -117,16 +146,28 @@ func setupUI(g *Game) {
 			widget.RowLayoutOpts.Direction(widget.DirectionVertical),
 			widget.RowLayoutOpts.Spacing(10),
 		),
-		), widget.ContainerOpts.WidgetOpts(widget.WidgetOpts.MinSize(100, 0)))
-
-	for _, button := range buttons {
-		buttonContainer.AddChild(button)
-	}
+		), widget.ContainerOpts.WidgetOpts(widget.WidgetOpts.MinSize(menuWidth, 0)))
 
+	buttonContainer.AddChild(elementButtons)
 	buttonContainer.AddChild(slider)
-	buttonContainer.AddChild(checkboxShowOnlyUpdated)
-	buttonContainer.AddChild(checkboxUpdateAllCells)
-	buttonContainer.AddChild(checkboxOnlyOneColor)
+	buttonContainer.AddChild(facingButton)
+	buttonContainer.AddChild(detonatorButton)
+	buttonContainer.AddChild(explosionPowerSlider)
+	buttonContainer.AddChild(gravityButton)
+	buttonContainer.AddChild(gravitySlider)
+	checkboxContainer := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Direction(widget.DirectionHorizontal),
+			widget.RowLayoutOpts.Spacing(8),
+		)),
+	)
+	checkboxContainer.AddChild(checkboxShowOnlyUpdated)
+	checkboxContainer.AddChild(checkboxUpdateAllCells)
+	checkboxContainer.AddChild(checkboxOnlyOneColor)
+	checkboxContainer.AddChild(checkboxShowAwakeChunks)
+	checkboxContainer.AddChild(checkboxShowTemperature)
+	buttonContainer.AddChild(checkboxContainer)
+	buttonContainer.AddChild(saveButton)
 
 	brushButtonContainer := widget.NewContainer(
 		widget.ContainerOpts.Layout(widget.NewRowLayout(
@@ -141,7 +182,7 @@ func setupUI(g *Game) {
 		widget.ContainerOpts.Layout(widget.NewRowLayout(
 			widget.RowLayoutOpts.Padding(
 				widget.Insets{
-					Left:   screenWidth,
+					Left:   g.screenWidth(),
 					Right:  0,
 					Top:    0,
 					Bottom: 0,
@@ -155,21 +196,38 @@ func setupUI(g *Game) {
 	}
 }
 
-func createButton(g *Game, res *resources, label string, cellType CellType) *widget.Button {
+func createButton(g *Game, res *resources, label string, cellType sim.CellType) *widget.Button {
 	return widget.NewButton(
-		widget.ButtonOpts.WidgetOpts(widget.WidgetOpts.LayoutData(widget.RowLayoutData{Stretch: true})),
 		widget.ButtonOpts.Image(res.buttonImage),
 		widget.ButtonOpts.Text(label, res.font, res.textColor),
-		widget.ButtonOpts.TextPadding(res.padding),
+		widget.ButtonOpts.TextPadding(widget.Insets{Left: 2, Right: 2, Top: 5, Bottom: 5}),
 		widget.ButtonOpts.ClickedHandler(func(*widget.ButtonClickedEventArgs) {
 			g.selectedCellType = cellType
+			g.detonator = false
 		}),
 	)
 }
 
-type buttonData struct {
-	label    string
-	cellType CellType
+func createCheckbox(res *resources, changed func(checked bool)) *widget.Checkbox {
+	return widget.NewCheckbox(
+		widget.CheckboxOpts.ButtonOpts(
+			widget.ButtonOpts.WidgetOpts(
+				// Set the location of the checkbox
+				widget.WidgetOpts.LayoutData(widget.AnchorLayoutData{
+					HorizontalPosition: widget.AnchorLayoutPositionCenter,
+					VerticalPosition:   widget.AnchorLayoutPositionCenter,
+				}),
+				// Set the minimum size of the checkbox
+				widget.WidgetOpts.MinSize(30, 30),
+			),
+			// Set the background images - idle, hover, pressed
+			widget.ButtonOpts.Image(res.buttonImage),
+		),
+		widget.CheckboxOpts.Image(res.checkboxImage),
+		widget.CheckboxOpts.StateChangedHandler(func(args *widget.CheckboxChangedEventArgs) {
+			changed(args.State == widget.WidgetChecked)
+		}),
+	)
 }
 
 func newResources() *resources {
@@ -1,49 +1,76 @@
 package main
 
-import "github.com/hajimehoshi/ebiten/v2"
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"go_project/sim"
+	"log"
+)
 
 func handleClick(g *Game) {
+	if g.detonator {
+		handleDetonator(g)
+		return
+	}
 	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
 		x, y := ebiten.CursorPosition()
 
-		if x <= screenWidth {
+		if x < g.screenWidth() {
 			isChangingBrush = false
 
 			cellX := x / cellSize
 			cellY := y / cellSize
-			cellConstructor := getCellConstructor(g)
+			// clicking a switch with the switch element selected turns it
+			// on or off instead of painting
+			if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && g.world.InBounds(cellX, cellY) &&
+				g.world.Get(cellX, cellY).Type == g.selectedCellType && g.world.Toggle(cellX, cellY) {
+				g.toggling = true
+			}
+			if g.toggling {
+				return
+			}
+			// Air and black holes are painted over anything, other elements
+			// only fill empty cells
+			blackHole, hasBlackHole := sim.ElementByName("Black Hole")
+			overwrite := g.selectedCellType == sim.Air || (hasBlackHole && g.selectedCellType == blackHole)
 			for offsetY := -g.brushSize; offsetY <= g.brushSize; offsetY++ {
 				for offsetX := -g.brushSize; offsetX <= g.brushSize; offsetX++ {
 					targetX := cellX + offsetX
 					targetY := cellY + offsetY
-					if targetX >= 0 && targetX < gridSize && targetY >= 0 && targetY < gridSize {
-						if g.selectedCellType == Air || g.selectedCellType == BlackHole || g.grid[targetY][targetX].cellType == Air {
-							g.grid[targetY][targetX] = cellConstructor()
+					if g.world.InBounds(targetX, targetY) {
+						if overwrite || g.world.Get(targetX, targetY).Type == sim.Air {
+							g.world.Set(targetX, targetY, g.world.NewCellFacing(g.selectedCellType, g.facing))
 						}
 					}
 				}
 			}
 		}
+	} else {
+		g.toggling = false
+	}
+}
+
+// handleDetonator sets off one explosion per click.
+func handleDetonator(g *Game) {
+	if !inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		return
+	}
+	x, y := ebiten.CursorPosition()
+	if x < g.screenWidth() && g.world.InBounds(x/cellSize, y/cellSize) {
+		g.world.Explode(x/cellSize, y/cellSize, g.explosionPower)
 	}
 }
 
-func getCellConstructor(g *Game) func() Cell {
-	cellConstructor := func() Cell {
-		return Cell{}
+// saveScene writes the world to the scene file given with -scene, or to
+// scene.json when none was given.
+func saveScene(g *Game) {
+	path := scenePath
+	if path == "" {
+		path = "scene.json"
 	}
-	switch g.selectedCellType {
-	case Sand:
-		cellConstructor = NewSandCell
-	case Water:
-		cellConstructor = NewWaterCell
-	case Air:
-		cellConstructor = NewAirCell
-	case Metal:
-		cellConstructor = NewMetalCell
-	case BlackHole:
-		cellConstructor = NewBlackHoleCell
-	case WaterGenerator:
-		cellConstructor = NewWaterGeneratorCell
+	if err := g.world.SaveScene(path); err != nil {
+		log.Println(err)
+		return
 	}
-	return cellConstructor
+	log.Println("scene saved to", path)
 }